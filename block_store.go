@@ -0,0 +1,19 @@
+package ipldpolymorph
+
+import (
+	"context"
+	"encoding/json"
+)
+
+// BlockStore is the interface for reading
+// and writing IPLD blocks.
+type BlockStore interface {
+	// Get returns the raw JSON stored
+	// at ref. Returns an error if the
+	// block could not be retrieved
+	Get(ctx context.Context, ref string) (json.RawMessage, error)
+
+	// Put stores the raw JSON and
+	// returns the ref it is stored at.
+	Put(ctx context.Context, raw json.RawMessage) (string, error)
+}
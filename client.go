@@ -0,0 +1,170 @@
+package ipldpolymorph
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/url"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// DefaultClient is the Client used by New, FromRef,
+// FromInterface, by every Polymorph that was not
+// constructed by a Client, and by the package level
+// functions taking an IPFS URL, e.g. ResolveRef
+var DefaultClient = &Client{}
+
+// Client holds the configuration used to resolve the
+// IPLD references of every Polymorph it constructs. A
+// Client is safe for concurrent use, but its fields
+// should not be modified once it is in use.
+type Client struct {
+	// URL is the IPFS API endpoint. DefaultIPFSURL
+	// is used if it is nil.
+	URL *url.URL
+
+	// HTTPClient is used to talk to the IPFS API.
+	// http.DefaultClient is used if it is nil.
+	HTTPClient *http.Client
+
+	// Cache is shared by every Polymorph constructed
+	// by the Client. If it is nil, every constructed
+	// Polymorph creates a Cache of its own.
+	Cache Cache
+
+	// BlockStore replaces the IPFS API as the source
+	// of IPLD references when it is set.
+	BlockStore BlockStore
+
+	// Retry is applied to every BlockStore call
+	// when it is set.
+	Retry *RetryPolicy
+
+	// Timeout limits how long every individual
+	// BlockStore call may take. Zero means
+	// there is no limit.
+	Timeout time.Duration
+
+	// MaxLinkHops limits how many IPLD references a single
+	// resolution may follow. DefaultMaxLinkHops is used
+	// if it is not set.
+	MaxLinkHops int
+
+	// Concurrency limits how many IPLD references are
+	// fetched in parallel. DefaultConcurrency is used
+	// if it is not set.
+	Concurrency int
+}
+
+// NewClient returns a Client talking
+// to the IPFS API at ipfsURL
+func NewClient(ipfsURL *url.URL) *Client {
+	return &Client{URL: ipfsURL}
+}
+
+// New Constructs a new Polymorph instance
+func (c *Client) New() *Polymorph {
+	return &Polymorph{client: c, Cache: c.Cache}
+}
+
+// FromRef instantiates a new Polymorph instance with a ref
+func (c *Client) FromRef(ref string) *Polymorph {
+	// Ignoring error, cause I could not
+	// figure out how to make this error:
+	// https://stackoverflow.com/questions/33903552/what-input-will-cause-golangs-json-marshal-to-return-an-error
+	link := map[string]string{"/": ref}
+	p, _ := c.FromInterface(link)
+	return p
+}
+
+// FromInterface instantiates a new Polymorph using json.Marshal
+// on the provided interface
+func (c *Client) FromInterface(data interface{}) (*Polymorph, error) {
+	p := c.New()
+	buf, err := json.Marshal(data)
+	if err != nil {
+		return nil, errors.Wrap(err, "Unable to json.Marshal")
+	}
+	err = p.UnmarshalJSON(buf)
+	if err != nil {
+		return nil, errors.Wrap(err, "Unable to UnmarshalJSON")
+	}
+	return p, nil
+}
+
+// ResolveRefs resolves every ref using the Client's
+// configuration. See ResolveRefsWithStore for details.
+func (c *Client) ResolveRefs(ctx context.Context, refs []string) map[string]RefResult {
+	cache := c.Cache
+	if cache == nil {
+		cache = NewSimpleCache()
+	}
+	return ResolveRefsWithStore(ctx, c.blockStore(nil), refs, cache, c.concurrency())
+}
+
+// blockStore returns the BlockStore to resolve IPLD
+// references with. ipfsURL overrides the Client's
+// BlockStore and URL if it is not nil.
+func (c *Client) blockStore(ipfsURL *url.URL) BlockStore {
+	store := c.BlockStore
+	if ipfsURL != nil || store == nil {
+		if ipfsURL == nil {
+			ipfsURL = c.ipfsURL()
+		}
+		store = &IPFSBlockStore{URL: ipfsURL, HTTPClient: c.HTTPClient}
+	}
+
+	if c.Timeout > 0 {
+		store = &timeoutBlockStore{store: store, timeout: c.Timeout}
+	}
+	if c.Retry != nil {
+		store = NewRetryBlockStore(store, *c.Retry)
+	}
+	return store
+}
+
+func (c *Client) ipfsURL() *url.URL {
+	if c.URL == nil {
+		return DefaultIPFSURL
+	}
+	return c.URL
+}
+
+func (c *Client) maxLinkHops() int {
+	if c.MaxLinkHops <= 0 {
+		return DefaultMaxLinkHops
+	}
+	return c.MaxLinkHops
+}
+
+func (c *Client) concurrency() int {
+	if c.Concurrency <= 0 {
+		return DefaultConcurrency
+	}
+	return c.Concurrency
+}
+
+// timeoutBlockStore limits how long every
+// call to the wrapped BlockStore may take
+type timeoutBlockStore struct {
+	store   BlockStore
+	timeout time.Duration
+}
+
+func (s *timeoutBlockStore) Get(ctx context.Context, ref string) (json.RawMessage, error) {
+	ctx, cancel := context.WithTimeout(ctx, s.timeout)
+	defer cancel()
+	return s.store.Get(ctx, ref)
+}
+
+func (s *timeoutBlockStore) Put(ctx context.Context, raw json.RawMessage) (string, error) {
+	ctx, cancel := context.WithTimeout(ctx, s.timeout)
+	defer cancel()
+	return s.store.Put(ctx, raw)
+}
+
+func (s *timeoutBlockStore) blockStoreKey() interface{} {
+	return blockStoreKey(s.store)
+}
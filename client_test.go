@@ -0,0 +1,212 @@
+package ipldpolymorph_test
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	ipldpolymorph "github.com/computes/go-ipld-polymorph"
+	"github.com/pkg/errors"
+)
+
+func TestClientFromRef(t *testing.T) {
+	beforeEach()
+	httpResponses[http.MethodGet]["/api/v0/dag/get?arg=foo"] = `{"bar":"red"}`
+	client := ipldpolymorph.NewClient(ipfsURL)
+
+	bar, err := client.FromRef("foo").GetString("bar")
+	if err != nil {
+		t.Fatal(`Could not GetString for path "bar":`, err.Error())
+	}
+
+	if bar != "red" {
+		t.Fatalf(`Expected bar == "red". Actual bar == "%v"`, bar)
+	}
+}
+
+func TestClientFromInterface(t *testing.T) {
+	beforeEach()
+	httpResponses[http.MethodGet]["/api/v0/dag/get?arg=foo"] = `"red"`
+	client := ipldpolymorph.NewClient(ipfsURL)
+
+	p, err := client.FromInterface(map[string]interface{}{
+		"bar": map[string]string{"/": "foo"},
+	})
+	if err != nil {
+		t.Fatal("Could not FromInterface:", err.Error())
+	}
+
+	bar, err := p.GetString("bar")
+	if err != nil {
+		t.Fatal(`Could not GetString for path "bar":`, err.Error())
+	}
+	if bar != "red" {
+		t.Fatalf(`Expected bar == "red". Actual bar == "%v"`, bar)
+	}
+}
+
+func TestClientSharedCache(t *testing.T) {
+	beforeEach()
+	httpResponses[http.MethodGet]["/api/v0/dag/get?arg=foo"] = `"bar"`
+	client := ipldpolymorph.NewClient(ipfsURL)
+	client.Cache = ipldpolymorph.NewSimpleCache()
+
+	_, err := client.FromRef("foo").AsString()
+	if err != nil {
+		t.Fatal(`Could not AsString:`, err.Error())
+	}
+
+	delete(httpResponses[http.MethodGet], "/api/v0/dag/get?arg=foo")
+	foo, err := client.FromRef("foo").AsString()
+	if err != nil {
+		t.Fatal(`Could not AsString:`, err.Error())
+	}
+	if foo != "bar" {
+		t.Fatalf(`Expected foo == "bar". Actual foo == "%v"`, foo)
+	}
+}
+
+func TestClientBlockStore(t *testing.T) {
+	memory := ipldpolymorph.NewMemoryBlockStore()
+	ref, err := memory.Put(context.Background(), json.RawMessage(`"bar"`))
+	if err != nil {
+		t.Fatal("Could not Put:", err.Error())
+	}
+	client := &ipldpolymorph.Client{BlockStore: memory}
+
+	foo, err := client.FromRef(ref).AsString()
+	if err != nil {
+		t.Fatal(`Could not AsString:`, err.Error())
+	}
+	if foo != "bar" {
+		t.Fatalf(`Expected foo == "bar". Actual foo == "%v"`, foo)
+	}
+}
+
+func TestClientRetry(t *testing.T) {
+	memory := ipldpolymorph.NewMemoryBlockStore()
+	ref, err := memory.Put(context.Background(), json.RawMessage(`"bar"`))
+	if err != nil {
+		t.Fatal("Could not Put:", err.Error())
+	}
+	flaky := &flakyBlockStore{
+		BlockStore: memory,
+		failures:   1,
+		err:        &ipldpolymorph.StatusError{StatusCode: http.StatusServiceUnavailable},
+	}
+	client := &ipldpolymorph.Client{BlockStore: flaky, Retry: &testRetryPolicy}
+
+	foo, err := client.FromRef(ref).AsString()
+	if err != nil {
+		t.Fatal(`Could not AsString:`, err.Error())
+	}
+	if foo != "bar" {
+		t.Fatalf(`Expected foo == "bar". Actual foo == "%v"`, foo)
+	}
+	if flaky.calls != 2 {
+		t.Fatalf(`Expected calls == 2. Actual calls == %v`, flaky.calls)
+	}
+}
+
+func TestClientTimeout(t *testing.T) {
+	store := &blockingBlockStore{BlockStore: ipldpolymorph.NewMemoryBlockStore(), release: make(chan struct{})}
+	defer close(store.release)
+	client := &ipldpolymorph.Client{BlockStore: &contextBlockStore{store}, Timeout: 10 * time.Millisecond}
+
+	_, err := client.FromRef("foo").AsString()
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatal("Expected AsString to return context.DeadlineExceeded, received", err)
+	}
+}
+
+func TestClientMaxLinkHops(t *testing.T) {
+	beforeEach()
+	httpResponses[http.MethodGet]["/api/v0/dag/get?arg=a"] = `{"/": "b"}`
+	httpResponses[http.MethodGet]["/api/v0/dag/get?arg=b"] = `"red"`
+	client := &ipldpolymorph.Client{URL: ipfsURL, MaxLinkHops: 1}
+
+	_, err := client.FromRef("a").AsString()
+	var chainErr *ipldpolymorph.LinkChainError
+	if !errors.As(err, &chainErr) {
+		t.Fatal("Expected AsString to return a LinkChainError, received", err)
+	}
+}
+
+// countingTransport counts the requests
+// it passes on to http.DefaultTransport
+type countingTransport struct {
+	requests int32
+}
+
+func (t *countingTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	atomic.AddInt32(&t.requests, 1)
+	return http.DefaultTransport.RoundTrip(req)
+}
+
+func TestDefaultClientPackageFunctions(t *testing.T) {
+	beforeEach()
+	httpResponses[http.MethodGet]["/api/v0/dag/get?arg=foo"] = `"bar"`
+	httpResponses[http.MethodPost]["/api/v0/dag/put?"] = `{"Cid":{"/":"foo"}}`
+	transport := &countingTransport{}
+	defaultClient := *ipldpolymorph.DefaultClient
+	ipldpolymorph.DefaultClient.HTTPClient = &http.Client{Transport: transport}
+	defer func() { *ipldpolymorph.DefaultClient = defaultClient }()
+
+	raw := json.RawMessage(`{"/":"foo"}`)
+	if _, err := ipldpolymorph.ResolveRef(ipfsURL, raw, ipldpolymorph.NewSimpleCache()); err != nil {
+		t.Fatal("Failed to ResolveRef:", err.Error())
+	}
+	if _, err := ipldpolymorph.CalcRef(ipfsURL, json.RawMessage(`"bar"`)); err != nil {
+		t.Fatal("Failed to CalcRef:", err.Error())
+	}
+	results := ipldpolymorph.ResolveRefs(context.Background(), ipfsURL, []string{"foo"}, ipldpolymorph.NewSimpleCache())
+	if results["foo"].Err != nil {
+		t.Fatal(`Failed to resolve "foo":`, results["foo"].Err.Error())
+	}
+
+	if requests := atomic.LoadInt32(&transport.requests); requests != 3 {
+		t.Fatalf(`Expected requests == 3. Actual requests == %v`, requests)
+	}
+}
+
+func TestClientResolveRefs(t *testing.T) {
+	beforeEach()
+	httpResponses[http.MethodGet]["/api/v0/dag/get?arg=foo"] = `"bar"`
+	client := ipldpolymorph.NewClient(ipfsURL)
+
+	results := client.ResolveRefs(context.Background(), []string{"foo"})
+	if results["foo"].Err != nil {
+		t.Fatal(`Failed to resolve "foo":`, results["foo"].Err.Error())
+	}
+	if string(results["foo"].Value) != `"bar"` {
+		t.Errorf(`Expected foo == "bar". Actual foo == %s`, results["foo"].Value)
+	}
+}
+
+// contextBlockStore makes Get return
+// as soon as ctx is done
+type contextBlockStore struct {
+	ipldpolymorph.BlockStore
+}
+
+func (s *contextBlockStore) Get(ctx context.Context, ref string) (json.RawMessage, error) {
+	type result struct {
+		value json.RawMessage
+		err   error
+	}
+	done := make(chan result, 1)
+	go func() {
+		value, err := s.BlockStore.Get(ctx, ref)
+		done <- result{value, err}
+	}()
+
+	select {
+	case res := <-done:
+		return res.value, res.err
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
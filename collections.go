@@ -0,0 +1,246 @@
+package ipldpolymorph
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"strconv"
+
+	"github.com/pkg/errors"
+)
+
+// AsPolymorphSlice returns the elements of the current array,
+// resolving the IPLD reference if necessary
+func (p *Polymorph) AsPolymorphSlice() ([]*Polymorph, error) {
+	return p.AsPolymorphSliceContext(context.Background())
+}
+
+// AsPolymorphSliceContext returns the elements of the current
+// array, resolving the IPLD reference if necessary. The elements
+// themselves are not resolved. Returns an error wrapping
+// ErrTypeMismatch if the value is not an array.
+func (p *Polymorph) AsPolymorphSliceContext(ctx context.Context) ([]*Polymorph, error) {
+	c, err := p.asContainer(ctx, KindArray)
+	if err != nil {
+		return nil, err
+	}
+
+	elements := make([]*Polymorph, len(c.array))
+	for i, element := range c.array {
+		elements[i] = p.derive(element)
+	}
+	return elements, nil
+}
+
+// AsPolymorphMap returns the members of the current object,
+// resolving the IPLD reference if necessary
+func (p *Polymorph) AsPolymorphMap() (map[string]*Polymorph, error) {
+	return p.AsPolymorphMapContext(context.Background())
+}
+
+// AsPolymorphMapContext returns the members of the current
+// object, resolving the IPLD reference if necessary. The members
+// themselves are not resolved. Returns an error wrapping
+// ErrTypeMismatch if the value is not an object.
+func (p *Polymorph) AsPolymorphMapContext(ctx context.Context) (map[string]*Polymorph, error) {
+	c, err := p.asContainer(ctx, KindObject)
+	if err != nil {
+		return nil, err
+	}
+
+	members := make(map[string]*Polymorph, len(c.object))
+	for key, member := range c.object {
+		members[key] = p.derive(member)
+	}
+	return members, nil
+}
+
+// AsStringSlice returns the current array as a slice of strings,
+// resolving IPLD references if necessary
+func (p *Polymorph) AsStringSlice() ([]string, error) {
+	return p.AsStringSliceContext(context.Background())
+}
+
+// AsStringSliceContext returns the current array as a slice of
+// strings, resolving the IPLD reference of the array and of every
+// element if necessary. Elements are fetched in parallel, as
+// limited by the Client's Concurrency. Returns an error wrapping
+// ErrTypeMismatch if the value is not an array, or if an element
+// is not a string.
+func (p *Polymorph) AsStringSliceContext(ctx context.Context) ([]string, error) {
+	c, err := p.asContainer(ctx, KindArray)
+	if err != nil {
+		return nil, err
+	}
+
+	keys := make([]string, len(c.array))
+	for i := range c.array {
+		keys[i] = strconv.Itoa(i)
+	}
+	resolved, err := p.resolveElements(ctx, keys, c.array)
+	if err != nil {
+		return nil, err
+	}
+
+	values := make([]string, len(resolved))
+	for i, element := range resolved {
+		if err := decodeElement(element, &values[i]); err != nil {
+			return nil, errors.Wrapf(err, "Unable to decode element %v", i)
+		}
+	}
+	return values, nil
+}
+
+// AsMap returns the current object as a map,
+// resolving IPLD references if necessary
+func (p *Polymorph) AsMap() (map[string]interface{}, error) {
+	return p.AsMapContext(context.Background())
+}
+
+// AsMapContext returns the current object as a map, resolving the
+// IPLD reference of the object and of every member if necessary.
+// Members are fetched in parallel, as limited by the Client's
+// Concurrency, and decoded like ToInterface does, except that
+// numbers are decoded as json.Number, so no precision is lost.
+// IPLD references nested deeper inside of the members are left
+// as they are.
+// Returns an error wrapping ErrTypeMismatch if the value is not
+// an object.
+func (p *Polymorph) AsMapContext(ctx context.Context) (map[string]interface{}, error) {
+	c, err := p.asContainer(ctx, KindObject)
+	if err != nil {
+		return nil, err
+	}
+
+	keys := make([]string, 0, len(c.object))
+	members := make([]json.RawMessage, 0, len(c.object))
+	for key, member := range c.object {
+		keys = append(keys, key)
+		members = append(members, member)
+	}
+	resolved, err := p.resolveElements(ctx, keys, members)
+	if err != nil {
+		return nil, err
+	}
+
+	decoded := make(map[string]interface{}, len(resolved))
+	for i, member := range resolved {
+		var value interface{}
+		if err := decodeElement(member, &value); err != nil {
+			return nil, errors.Wrapf(err, `Unable to decode member "%v"`, keys[i])
+		}
+		decoded[keys[i]] = value
+	}
+	return decoded, nil
+}
+
+// resolveElements resolves every IPLD reference among elements,
+// whose keys are used in error messages. The references are
+// fetched in parallel before any of them is followed.
+func (p *Polymorph) resolveElements(ctx context.Context, keys []string, elements []json.RawMessage) ([]json.RawMessage, error) {
+	var refs []string
+	for _, element := range elements {
+		if ref, err := AssertRef(element); err == nil {
+			refs = append(refs, ref)
+		}
+	}
+	results := p.ResolveRefs(ctx, refs)
+
+	resolved := make([]json.RawMessage, len(elements))
+	for i, element := range elements {
+		if ref, err := AssertRef(element); err == nil && results[ref].Err != nil {
+			return nil, errors.Wrapf(results[ref].Err, `Unable to resolve "%v"`, keys[i])
+		}
+
+		var err error
+		resolved[i], _, err = p.resolve(ctx, element, nil)
+		if err != nil {
+			return nil, errors.Wrapf(err, `Unable to resolve "%v"`, keys[i])
+		}
+	}
+	return resolved, nil
+}
+
+// decodeElement decodes the raw JSON into data, keeping numbers
+// decoded into an interface{} as json.Number. Returns an error
+// wrapping ErrTypeMismatch if it doesn't fit.
+func decodeElement(raw json.RawMessage, data interface{}) error {
+	decoder := json.NewDecoder(bytes.NewReader(raw))
+	decoder.UseNumber()
+	err := decoder.Decode(data)
+	if typeErr, ok := err.(*json.UnmarshalTypeError); ok {
+		return errors.Wrap(ErrTypeMismatch, typeErr.Error())
+	}
+	if err != nil {
+		return errors.Wrap(err, "Unmarshal failed")
+	}
+	return nil
+}
+
+// GetPolymorphSlice returns the elements of the array at path,
+// resolving IPLD references if necessary to get there.
+func (p *Polymorph) GetPolymorphSlice(path string) ([]*Polymorph, error) {
+	return p.GetPolymorphSliceContext(context.Background(), path)
+}
+
+// GetPolymorphSliceContext returns the elements of the array at path,
+// resolving IPLD references if necessary to get there.
+func (p *Polymorph) GetPolymorphSliceContext(ctx context.Context, path string) ([]*Polymorph, error) {
+	poly, err := p.GetPolymorphContext(ctx, path)
+	if err != nil {
+		return nil, errors.Wrap(err, "GetPolymorph failed")
+	}
+
+	return poly.AsPolymorphSliceContext(ctx)
+}
+
+// GetPolymorphMap returns the members of the object at path,
+// resolving IPLD references if necessary to get there.
+func (p *Polymorph) GetPolymorphMap(path string) (map[string]*Polymorph, error) {
+	return p.GetPolymorphMapContext(context.Background(), path)
+}
+
+// GetPolymorphMapContext returns the members of the object at path,
+// resolving IPLD references if necessary to get there.
+func (p *Polymorph) GetPolymorphMapContext(ctx context.Context, path string) (map[string]*Polymorph, error) {
+	poly, err := p.GetPolymorphContext(ctx, path)
+	if err != nil {
+		return nil, errors.Wrap(err, "GetPolymorph failed")
+	}
+
+	return poly.AsPolymorphMapContext(ctx)
+}
+
+// GetStringSlice returns the array of strings at path,
+// resolving IPLD references if necessary to get there.
+func (p *Polymorph) GetStringSlice(path string) ([]string, error) {
+	return p.GetStringSliceContext(context.Background(), path)
+}
+
+// GetStringSliceContext returns the array of strings at path,
+// resolving IPLD references if necessary to get there.
+func (p *Polymorph) GetStringSliceContext(ctx context.Context, path string) ([]string, error) {
+	poly, err := p.GetPolymorphContext(ctx, path)
+	if err != nil {
+		return nil, errors.Wrap(err, "GetPolymorph failed")
+	}
+
+	return poly.AsStringSliceContext(ctx)
+}
+
+// GetMap returns the object at path as a map,
+// resolving IPLD references if necessary to get there.
+func (p *Polymorph) GetMap(path string) (map[string]interface{}, error) {
+	return p.GetMapContext(context.Background(), path)
+}
+
+// GetMapContext returns the object at path as a map,
+// resolving IPLD references if necessary to get there.
+func (p *Polymorph) GetMapContext(ctx context.Context, path string) (map[string]interface{}, error) {
+	poly, err := p.GetPolymorphContext(ctx, path)
+	if err != nil {
+		return nil, errors.Wrap(err, "GetPolymorph failed")
+	}
+
+	return poly.AsMapContext(ctx)
+}
@@ -0,0 +1,109 @@
+package ipldpolymorph_test
+
+import (
+	"encoding/json"
+	"net/http"
+	"strings"
+	"testing"
+
+	ipldpolymorph "github.com/computes/go-ipld-polymorph"
+	"github.com/pkg/errors"
+)
+
+func TestGetStringSlice(t *testing.T) {
+	memory := ipldpolymorph.NewMemoryBlockStore()
+	refs := putLinked(t, memory, `"a"`, `"b"`, `"c"`, `"d"`)
+	store := &slowBlockStore{BlockStore: memory}
+	client := &ipldpolymorph.Client{BlockStore: store, Concurrency: 4}
+
+	p, err := client.FromInterface(map[string]interface{}{
+		"list": []interface{}{
+			map[string]string{"/": refs[0]},
+			map[string]string{"/": refs[1]},
+			"inline",
+			map[string]string{"/": refs[2]},
+			map[string]string{"/": refs[3]},
+		},
+	})
+	if err != nil {
+		t.Fatal("Could not FromInterface:", err.Error())
+	}
+
+	list, err := p.GetStringSlice("list")
+	if err != nil {
+		t.Fatal(`Could not GetStringSlice for path "list":`, err.Error())
+	}
+	if strings.Join(list, ",") != "a,b,inline,c,d" {
+		t.Fatalf(`Expected list == "a,b,inline,c,d". Actual list == "%v"`, strings.Join(list, ","))
+	}
+	if store.maxInFlight < 2 {
+		t.Fatalf(`Expected the elements to be fetched in parallel. Actual maxInFlight == %v`, store.maxInFlight)
+	}
+}
+
+func TestAsStringSliceTypeMismatch(t *testing.T) {
+	p, err := ipldpolymorph.FromInterface(ipfsURL, []interface{}{"a", 1})
+	if err != nil {
+		t.Fatal("Could not FromInterface:", err.Error())
+	}
+
+	_, err = p.AsStringSlice()
+	if !errors.Is(err, ipldpolymorph.ErrTypeMismatch) {
+		t.Fatalf("Expected err to be ErrTypeMismatch. Actual err == %v", err)
+	}
+}
+
+func TestGetMap(t *testing.T) {
+	beforeEach()
+	httpResponses[http.MethodGet]["/api/v0/dag/get?arg=foo"] = `{"obj":{"bar":{"/":"bar"},"baz":1}}`
+	httpResponses[http.MethodGet]["/api/v0/dag/get?arg=bar"] = `{"nested":{"/":"nested"}}`
+	p := ipldpolymorph.FromRef(ipfsURL, "foo")
+
+	obj, err := p.GetMap("obj")
+	if err != nil {
+		t.Fatal(`Could not GetMap for path "obj":`, err.Error())
+	}
+	buf, _ := json.Marshal(obj)
+	if string(buf) != `{"bar":{"nested":{"/":"nested"}},"baz":1}` {
+		t.Fatalf(`Expected obj == {"bar":{"nested":{"/":"nested"}},"baz":1}. Actual obj == %s`, buf)
+	}
+}
+
+func TestAsMapLargeIntegers(t *testing.T) {
+	p, err := ipldpolymorph.FromInterface(ipfsURL, json.RawMessage(`{"big":9007199254740993,"nested":{"big":9007199254740993}}`))
+	if err != nil {
+		t.Fatal("Could not FromInterface:", err.Error())
+	}
+
+	obj, err := p.AsMap()
+	if err != nil {
+		t.Fatal("Could not AsMap:", err.Error())
+	}
+	if obj["big"] != json.Number("9007199254740993") {
+		t.Fatalf(`Expected big == 9007199254740993. Actual big == %v`, obj["big"])
+	}
+	nested := obj["nested"].(map[string]interface{})
+	if nested["big"] != json.Number("9007199254740993") {
+		t.Fatalf(`Expected nested.big == 9007199254740993. Actual nested.big == %v`, nested["big"])
+	}
+}
+
+func TestGetPolymorphSlice(t *testing.T) {
+	p, err := ipldpolymorph.FromInterface(ipfsURL, map[string]interface{}{
+		"list": []interface{}{map[string]string{"/": "never-fetched"}, "b"},
+	})
+	if err != nil {
+		t.Fatal("Could not FromInterface:", err.Error())
+	}
+
+	list, err := p.GetPolymorphSlice("list")
+	if err != nil {
+		t.Fatal(`Could not GetPolymorphSlice for path "list":`, err.Error())
+	}
+	if len(list) != 2 {
+		t.Fatalf(`Expected len(list) == 2. Actual len(list) == %v`, len(list))
+	}
+	if list[0].AsRef() != "never-fetched" {
+		t.Fatalf(`Expected list[0] ref == "never-fetched". Actual list[0] ref == "%v"`, list[0].AsRef())
+	}
+}
@@ -0,0 +1,110 @@
+package ipldpolymorph
+
+import (
+	"context"
+	"encoding/json"
+	"sort"
+
+	"github.com/pkg/errors"
+)
+
+// Commit writes the edited document to the BlockStore.
+// See CommitContext for details.
+func (p *Polymorph) Commit() (string, []string, error) {
+	return p.CommitContext(context.Background())
+}
+
+// CommitContext writes the edited document to the BlockStore and
+// returns the ref of its root, along with the refs of every block
+// written, in the order they were written. Every IPLD reference
+// that Set and the other edits replaced with its content is
+// written back as a block of its own, bottom-up, and replaced by a
+// link to it in its parent. Unchanged references are not written
+// again. If p is an unedited IPLD reference, its ref is returned
+// and nothing is written.
+func (p *Polymorph) CommitContext(ctx context.Context) (string, []string, error) {
+	if p.raw == nil {
+		return "", nil, errors.Errorf("Polymorph.raw is nil")
+	}
+	if p.IsRef() {
+		return p.AsRef(), nil, nil
+	}
+
+	c := &committer{store: p.blockStore()}
+	raw, err := c.commit(ctx, p.raw, p.links, Path{})
+	if err != nil {
+		return "", nil, err
+	}
+
+	ref, err := c.put(ctx, raw, Path{})
+	if err != nil {
+		return "", nil, err
+	}
+	return ref, c.written, nil
+}
+
+// committer holds the state of a single call to Commit
+type committer struct {
+	store   BlockStore
+	written []string
+}
+
+// commit writes the references inlined inside of raw, which
+// is the value at path, as recorded by trie, and returns raw
+// with every one of them replaced by a link
+func (c *committer) commit(ctx context.Context, raw json.RawMessage, trie *linkTrie, path Path) (json.RawMessage, error) {
+	if trie == nil || len(trie.children) == 0 {
+		return raw, nil
+	}
+
+	parsed, err := decodeContainer(raw)
+	if err != nil {
+		return nil, errors.Wrapf(err, `Unable to decode "%v"`, path)
+	}
+
+	keys := make([]string, 0, len(trie.children))
+	for key := range trie.children {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	for _, key := range keys {
+		child, _, ok := parsed.get(key)
+		if !ok {
+			return nil, errors.Errorf(`edited value "%v" is missing`, path.Append(key))
+		}
+
+		childTrie := trie.children[key]
+		child, err = c.commit(ctx, child, childTrie, path.Append(key))
+		if err != nil {
+			return nil, err
+		}
+
+		if childTrie.inlined {
+			ref, err := c.put(ctx, child, path.Append(key))
+			if err != nil {
+				return nil, err
+			}
+			child = refLink(ref)
+		}
+		parsed.set(key, child)
+	}
+
+	return parsed.encode()
+}
+
+// put writes raw, which is the value at path, to the BlockStore
+func (c *committer) put(ctx context.Context, raw json.RawMessage, path Path) (string, error) {
+	ref, err := c.store.Put(ctx, raw)
+	if err != nil {
+		return "", errors.Wrapf(err, `Unable to Put "%v"`, path)
+	}
+	c.written = append(c.written, ref)
+	return ref, nil
+}
+
+// refLink returns the raw JSON of an IPLD reference to ref
+func refLink(ref string) json.RawMessage {
+	buf, _ := json.Marshal(map[string]string{"/": ref})
+	return json.RawMessage(buf)
+}
@@ -0,0 +1,78 @@
+package ipldpolymorph_test
+
+import (
+	"testing"
+
+	ipldpolymorph "github.com/computes/go-ipld-polymorph"
+)
+
+func TestCommitAfterSet(t *testing.T) {
+	store := ipldpolymorph.NewMemoryBlockStore()
+	refs := putLinked(t, store,
+		`{"c":"red"}`,
+		`{"b":{"/":"$0"},"sibling":{"/":"$0"}}`,
+		`{"a":{"/":"$1"}}`,
+	)
+	p := ipldpolymorph.NewWithBlockStore(store)
+	p.UnmarshalJSON([]byte(`{"/":"` + refs[2] + `"}`))
+
+	edited, err := p.Set("a/b/c", "blue")
+	if err != nil {
+		t.Fatal(`Could not Set "a/b/c":`, err.Error())
+	}
+
+	root, written, err := edited.Commit()
+	if err != nil {
+		t.Fatal("Could not Commit:", err.Error())
+	}
+	if len(written) != 3 {
+		t.Fatalf(`Expected len(written) == 3. Actual len(written) == %v`, len(written))
+	}
+	if written[len(written)-1] != root {
+		t.Fatalf(`Expected the root "%v" to be written last. Actual written == %v`, root, written)
+	}
+
+	committed := ipldpolymorph.NewWithBlockStore(store)
+	committed.UnmarshalJSON([]byte(`{"/":"` + root + `"}`))
+
+	c, err := committed.GetString("a/b/c")
+	if err != nil {
+		t.Fatal(`Could not GetString for path "a/b/c":`, err.Error())
+	}
+	if c != "blue" {
+		t.Fatalf(`Expected c == "blue". Actual c == "%v"`, c)
+	}
+
+	b, err := committed.GetUnresolvedPolymorph("a/b")
+	if err != nil {
+		t.Fatal(`Could not GetUnresolvedPolymorph for path "a/b":`, err.Error())
+	}
+	if !b.IsRef() {
+		t.Fatal(`Expected "a/b" to be committed as a ref`)
+	}
+
+	sibling, err := committed.GetUnresolvedPolymorph("a/sibling")
+	if err != nil {
+		t.Fatal(`Could not GetUnresolvedPolymorph for path "a/sibling":`, err.Error())
+	}
+	if sibling.AsRef() != refs[0] {
+		t.Fatalf(`Expected sibling ref == "%v". Actual sibling ref == "%v"`, refs[0], sibling.AsRef())
+	}
+}
+
+func TestCommitUneditedRef(t *testing.T) {
+	store := ipldpolymorph.NewMemoryBlockStore()
+	p := ipldpolymorph.NewWithBlockStore(store)
+	p.UnmarshalJSON([]byte(`{"/":"foo"}`))
+
+	root, written, err := p.Commit()
+	if err != nil {
+		t.Fatal("Could not Commit:", err.Error())
+	}
+	if root != "foo" {
+		t.Fatalf(`Expected root == "foo". Actual root == "%v"`, root)
+	}
+	if len(written) != 0 {
+		t.Fatalf(`Expected len(written) == 0. Actual len(written) == %v`, len(written))
+	}
+}
@@ -0,0 +1,192 @@
+package ipldpolymorph
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"sort"
+
+	"github.com/pkg/errors"
+)
+
+// ChangeType identifies the kind of a Change
+type ChangeType int
+
+const (
+	// Added means the value only exists in the new document
+	Added ChangeType = iota
+
+	// Removed means the value only exists in the old document
+	Removed
+
+	// Changed means the value exists in both
+	// documents, but is different
+	Changed
+)
+
+// String returns the name of the ChangeType
+func (t ChangeType) String() string {
+	switch t {
+	case Added:
+		return "added"
+	case Removed:
+		return "removed"
+	case Changed:
+		return "changed"
+	}
+	return "unknown"
+}
+
+// Change is a single difference found by Diff. From and
+// To hold the old and the new value as they are stored in
+// their documents, so they may be IPLD references. From is
+// nil for Added values, To is nil for Removed values.
+type Change struct {
+	Type ChangeType
+	Path Path
+	From json.RawMessage
+	To   json.RawMessage
+}
+
+// Diff compares the logical documents a and b.
+// See DiffContext for details.
+func Diff(a, b *Polymorph) ([]Change, error) {
+	return DiffContext(context.Background(), a, b)
+}
+
+// DiffContext compares the logical documents a and b, in which
+// IPLD references are replaced by their content, and returns the
+// changes that turn a into b. Two IPLD references to the same
+// ref are equal, so the subtree behind them is never fetched.
+// Objects are compared key by key, in sorted order, and arrays
+// index by index. Elements appended to an array are reported in
+// ascending order, elements removed from its end in descending
+// order, so the changes can be applied one after another. Any
+// other difference is reported as Changed.
+func DiffContext(ctx context.Context, a, b *Polymorph) ([]Change, error) {
+	if a.raw == nil || b.raw == nil {
+		return nil, errors.Errorf("Polymorph.raw is nil")
+	}
+
+	d := &differ{a: a, b: b}
+	err := d.diff(ctx, a.raw, b.raw, nil, nil, Path{})
+	if err != nil {
+		return nil, err
+	}
+	return d.changes, nil
+}
+
+// differ holds the state of a single call to Diff
+type differ struct {
+	a, b    *Polymorph
+	changes []Change
+}
+
+// diff records the changes between from and to, which are the
+// values at path. aChain and bChain hold the refs followed in
+// each document to get to them.
+func (d *differ) diff(ctx context.Context, from, to json.RawMessage, aChain, bChain []string, path Path) error {
+	fromRef, fromErr := AssertRef(from)
+	toRef, toErr := AssertRef(to)
+	if fromErr == nil && toErr == nil && fromRef == toRef {
+		return nil
+	}
+
+	resolvedFrom, aChain, err := d.a.resolve(ctx, from, aChain)
+	if err != nil {
+		return errors.Wrapf(err, `Unable to resolve "%v" in a`, path)
+	}
+	resolvedTo, bChain, err := d.b.resolve(ctx, to, bChain)
+	if err != nil {
+		return errors.Wrapf(err, `Unable to resolve "%v" in b`, path)
+	}
+
+	first := firstByte(resolvedFrom)
+	if !isContainer(resolvedFrom) || first != firstByte(resolvedTo) {
+		equal, err := jsonEqual(resolvedFrom, resolvedTo)
+		if err != nil {
+			return errors.Wrapf(err, `Unable to compare "%v"`, path)
+		}
+		if !equal {
+			d.changes = append(d.changes, Change{Type: Changed, Path: path, From: from, To: to})
+		}
+		return nil
+	}
+
+	fromContainer, err := decodeContainer(resolvedFrom)
+	if err != nil {
+		return errors.Wrapf(err, `Unable to decode "%v" in a`, path)
+	}
+	toContainer, err := decodeContainer(resolvedTo)
+	if err != nil {
+		return errors.Wrapf(err, `Unable to decode "%v" in b`, path)
+	}
+
+	if fromContainer.isArray {
+		return d.diffArrays(ctx, fromContainer.array, toContainer.array, aChain, bChain, path)
+	}
+	return d.diffObjects(ctx, fromContainer.object, toContainer.object, aChain, bChain, path)
+}
+
+func (d *differ) diffObjects(ctx context.Context, from, to map[string]json.RawMessage, aChain, bChain []string, path Path) error {
+	keys := make([]string, 0, len(from)+len(to))
+	for key := range from {
+		keys = append(keys, key)
+	}
+	for key := range to {
+		if _, ok := from[key]; !ok {
+			keys = append(keys, key)
+		}
+	}
+	sort.Strings(keys)
+
+	for _, key := range keys {
+		fromValue, inFrom := from[key]
+		toValue, inTo := to[key]
+		switch {
+		case !inFrom:
+			d.changes = append(d.changes, Change{Type: Added, Path: path.Append(key), To: toValue})
+		case !inTo:
+			d.changes = append(d.changes, Change{Type: Removed, Path: path.Append(key), From: fromValue})
+		default:
+			if err := d.diff(ctx, fromValue, toValue, aChain, bChain, path.Append(key)); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+func (d *differ) diffArrays(ctx context.Context, from, to []json.RawMessage, aChain, bChain []string, path Path) error {
+	common := len(from)
+	if len(to) < common {
+		common = len(to)
+	}
+
+	for i := 0; i < common; i++ {
+		if err := d.diff(ctx, from[i], to[i], aChain, bChain, path.AppendIndex(i)); err != nil {
+			return err
+		}
+	}
+	for i := common; i < len(to); i++ {
+		d.changes = append(d.changes, Change{Type: Added, Path: path.AppendIndex(i), To: to[i]})
+	}
+	for i := len(from) - 1; i >= common; i-- {
+		d.changes = append(d.changes, Change{Type: Removed, Path: path.AppendIndex(i), From: from[i]})
+	}
+	return nil
+}
+
+// jsonEqual reports whether the raw JSON values
+// are the same, ignoring insignificant whitespace
+func jsonEqual(a, b json.RawMessage) (bool, error) {
+	compactA := &bytes.Buffer{}
+	if err := json.Compact(compactA, a); err != nil {
+		return false, errors.Wrap(err, "Compact failed")
+	}
+	compactB := &bytes.Buffer{}
+	if err := json.Compact(compactB, b); err != nil {
+		return false, errors.Wrap(err, "Compact failed")
+	}
+	return bytes.Equal(compactA.Bytes(), compactB.Bytes()), nil
+}
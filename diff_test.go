@@ -0,0 +1,59 @@
+package ipldpolymorph_test
+
+import (
+	"testing"
+
+	ipldpolymorph "github.com/computes/go-ipld-polymorph"
+)
+
+func TestDiff(t *testing.T) {
+	store := ipldpolymorph.NewMemoryBlockStore()
+	refs := putLinked(t, store,
+		`{"k":1,"same":true}`,
+		`{"shared":{"/":"never-stored"},"nested":{"/":"$0"},"changed":"x","removed":1,"list":[1,2,3]}`,
+	)
+	a := ipldpolymorph.NewWithBlockStore(store)
+	a.UnmarshalJSON([]byte(`{"/":"` + refs[1] + `"}`))
+	b := ipldpolymorph.NewWithBlockStore(store)
+	b.UnmarshalJSON([]byte(`{"shared":{"/":"never-stored"},"nested":{"k":2,"same":true},"changed":"y","added":true,"list":[1]}`))
+
+	changes, err := ipldpolymorph.Diff(a, b)
+	if err != nil {
+		t.Fatal("Could not Diff:", err.Error())
+	}
+
+	expected := []struct {
+		changeType ipldpolymorph.ChangeType
+		path       string
+	}{
+		{ipldpolymorph.Added, "/added"},
+		{ipldpolymorph.Changed, "/changed"},
+		{ipldpolymorph.Removed, "/list/2"},
+		{ipldpolymorph.Removed, "/list/1"},
+		{ipldpolymorph.Changed, "/nested/k"},
+		{ipldpolymorph.Removed, "/removed"},
+	}
+	if len(changes) != len(expected) {
+		t.Fatalf(`Expected len(changes) == %v. Actual changes == %v`, len(expected), changes)
+	}
+	for i, change := range changes {
+		if change.Type != expected[i].changeType || change.Path.String() != expected[i].path {
+			t.Errorf(`Expected changes[%v] == %v "%v". Actual changes[%v] == %v "%v"`, i, expected[i].changeType, expected[i].path, i, change.Type, change.Path)
+		}
+	}
+}
+
+func TestDiffIdenticalRefs(t *testing.T) {
+	a := ipldpolymorph.NewWithBlockStore(ipldpolymorph.NewMemoryBlockStore())
+	a.UnmarshalJSON([]byte(`{"/":"never-stored"}`))
+	b := ipldpolymorph.NewWithBlockStore(ipldpolymorph.NewMemoryBlockStore())
+	b.UnmarshalJSON([]byte(`{"/":"never-stored"}`))
+
+	changes, err := ipldpolymorph.Diff(a, b)
+	if err != nil {
+		t.Fatal("Could not Diff:", err.Error())
+	}
+	if len(changes) != 0 {
+		t.Fatalf(`Expected len(changes) == 0. Actual changes == %v`, changes)
+	}
+}
@@ -0,0 +1,552 @@
+package ipldpolymorph
+
+import (
+	"context"
+	"encoding/json"
+	"strconv"
+
+	"github.com/pkg/errors"
+)
+
+// Set returns a new Polymorph in which the value at path is
+// replaced by value. See SetContext for details.
+func (p *Polymorph) Set(path string, value interface{}) (*Polymorph, error) {
+	return p.SetContext(context.Background(), path, value)
+}
+
+// SetContext returns a new Polymorph in which the value at path
+// is replaced by value, which is encoded using json.Marshal. The
+// parent of path must exist. Array elements are addressed by index,
+// and an index equal to the length of the array, or "-", appends
+// to it. IPLD references along the path are resolved and replaced
+// by their content, every other reference is left as it is, so
+// only the modified spine of the document is ever fetched. p itself
+// is not modified.
+func (p *Polymorph) SetContext(ctx context.Context, path string, value interface{}) (*Polymorph, error) {
+	parsed, err := ParsePath(path)
+	if err != nil {
+		return nil, errors.Wrap(err, "ParsePath failed")
+	}
+
+	raw, trie, err := encodeValue(value)
+	if err != nil {
+		return nil, err
+	}
+
+	return p.set(ctx, parsed, path, raw, trie)
+}
+
+// Delete returns a new Polymorph in which the value at
+// path is removed. See DeleteContext for details.
+func (p *Polymorph) Delete(path string) (*Polymorph, error) {
+	return p.DeleteContext(context.Background(), path)
+}
+
+// DeleteContext returns a new Polymorph in which the value at
+// path is removed. Removing an array element shifts the elements
+// after it. Fails with an error wrapping ErrPathNotFound if there
+// is no value at path. Like SetContext, only the IPLD references
+// along the path are resolved, and p itself is not modified.
+func (p *Polymorph) DeleteContext(ctx context.Context, path string) (*Polymorph, error) {
+	parsed, err := ParsePath(path)
+	if err != nil {
+		return nil, errors.Wrap(err, "ParsePath failed")
+	}
+
+	edited, _, _, err := p.remove(ctx, parsed, path)
+	return edited, err
+}
+
+// Move returns a new Polymorph in which the value at from
+// is moved to to. See MoveContext for details.
+func (p *Polymorph) Move(from, to string) (*Polymorph, error) {
+	return p.MoveContext(context.Background(), from, to)
+}
+
+// MoveContext returns a new Polymorph in which the value at from
+// is removed, and then stored at to, as if by DeleteContext and
+// SetContext. The value is moved as it is, so IPLD references
+// inside of it are not resolved. Fails with an error wrapping
+// ErrPathNotFound if there is no value at from, or if the
+// parent of to does not exist once from has been removed.
+func (p *Polymorph) MoveContext(ctx context.Context, from, to string) (*Polymorph, error) {
+	fromPath, err := ParsePath(from)
+	if err != nil {
+		return nil, errors.Wrap(err, "ParsePath failed")
+	}
+	toPath, err := ParsePath(to)
+	if err != nil {
+		return nil, errors.Wrap(err, "ParsePath failed")
+	}
+
+	return p.move(ctx, fromPath, from, toPath, to, false)
+}
+
+// move moves the value at from to to. If insert is true, the
+// value is inserted into arrays at to like add does, instead
+// of replacing the element there. fromDisplay and toDisplay
+// are the paths as the caller wrote them.
+func (p *Polymorph) move(ctx context.Context, from Path, fromDisplay string, to Path, toDisplay string, insert bool) (*Polymorph, error) {
+	if isPrefix(from, to) {
+		if len(from) == len(to) {
+			_, err := p.lookup(ctx, from, fromDisplay, false)
+			if err != nil {
+				return nil, err
+			}
+			return p.edited(p.raw, p.links), nil
+		}
+		return nil, errors.Errorf(`Unable to move "%v" into its own child "%v"`, fromDisplay, toDisplay)
+	}
+
+	edited, raw, trie, err := p.remove(ctx, from, fromDisplay)
+	if err != nil {
+		return nil, err
+	}
+	if insert {
+		return edited.add(ctx, to, toDisplay, raw, trie)
+	}
+	return edited.set(ctx, to, toDisplay, raw, trie)
+}
+
+// set replaces the value at path with raw, inside of which trie
+// records the inlined references. An empty path replaces the
+// whole document.
+func (p *Polymorph) set(ctx context.Context, path Path, display string, raw json.RawMessage, trie *linkTrie) (*Polymorph, error) {
+	if len(path) == 0 {
+		return p.edited(raw, trie), nil
+	}
+
+	return p.modify(ctx, path, display, func(c *container, key string, parent *linkTrie) error {
+		canonical, err := c.replace(key, raw)
+		if err != nil {
+			return err
+		}
+		parent.setChild(canonical, trie)
+		return nil
+	})
+}
+
+// add is like set, except that it inserts raw into arrays,
+// shifting the element at path and every one after it
+func (p *Polymorph) add(ctx context.Context, path Path, display string, raw json.RawMessage, trie *linkTrie) (*Polymorph, error) {
+	if len(path) == 0 {
+		return p.edited(raw, trie), nil
+	}
+
+	return p.modify(ctx, path, display, func(c *container, key string, parent *linkTrie) error {
+		canonical, err := c.insert(key, raw)
+		if err != nil {
+			return err
+		}
+		if c.isArray {
+			index, _ := strconv.Atoi(canonical)
+			parent.shift(index, 1)
+		}
+		parent.setChild(canonical, trie)
+		return nil
+	})
+}
+
+// extract returns the value at path, without resolving it, along
+// with the record of the references inlined inside of it
+func (p *Polymorph) extract(ctx context.Context, path Path, display string) (json.RawMessage, *linkTrie, error) {
+	if len(path) == 0 {
+		return p.raw, p.links, nil
+	}
+
+	var value json.RawMessage
+	var trie *linkTrie
+	_, err := p.modify(ctx, path, display, func(c *container, key string, parent *linkTrie) error {
+		var canonical string
+		var ok bool
+		value, canonical, ok = c.get(key)
+		if !ok {
+			return ErrPathNotFound
+		}
+		trie = parent.child(canonical)
+		return nil
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+	return value, trie, nil
+}
+
+// remove removes the value at path, and returns it along with
+// the record of the references inlined inside of it
+func (p *Polymorph) remove(ctx context.Context, path Path, display string) (*Polymorph, json.RawMessage, *linkTrie, error) {
+	if len(path) == 0 {
+		return nil, nil, nil, errors.New("Unable to remove the whole document")
+	}
+
+	var removed json.RawMessage
+	var removedTrie *linkTrie
+	edited, err := p.modify(ctx, path, display, func(c *container, key string, parent *linkTrie) error {
+		var err error
+		var canonical string
+		removed, canonical, err = c.remove(key)
+		if err != nil {
+			return err
+		}
+		removedTrie = parent.removeChild(canonical, c.isArray)
+		return nil
+	})
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	return edited, removed, removedTrie, nil
+}
+
+// isPrefix reports whether path starts with prefix
+func isPrefix(prefix, path Path) bool {
+	if len(prefix) > len(path) {
+		return false
+	}
+	for i, segment := range prefix {
+		if path[i] != segment {
+			return false
+		}
+	}
+	return true
+}
+
+// encodeValue returns the raw JSON of value. If value is a
+// *Polymorph, its raw JSON is used as is, together with the
+// record of the references that edits to it inlined.
+func encodeValue(value interface{}) (json.RawMessage, *linkTrie, error) {
+	if poly, ok := value.(*Polymorph); ok {
+		if poly.raw == nil {
+			return nil, nil, errors.Errorf("Polymorph.raw is nil")
+		}
+		return poly.raw, poly.links, nil
+	}
+
+	buf, err := json.Marshal(value)
+	if err != nil {
+		return nil, nil, errors.Wrap(err, "Unable to json.Marshal")
+	}
+	return json.RawMessage(buf), nil, nil
+}
+
+// edited returns a new Polymorph for raw that was produced
+// by editing p, inlining the references recorded in trie
+func (p *Polymorph) edited(raw json.RawMessage, trie *linkTrie) *Polymorph {
+	value := p.derive(raw)
+	value.links = trie
+	return value
+}
+
+// modifyFunc changes the container holding the last segment
+// of the path being modified. key is that last segment, and
+// parent records the references inlined inside the container.
+type modifyFunc func(c *container, key string, parent *linkTrie) error
+
+// modify returns a new Polymorph in which op has been applied to
+// the container holding the last segment of path. Every IPLD
+// reference leading up to that container is resolved and
+// replaced by its content. path must not be empty. display is
+// the path as the caller wrote it, and is used in error messages.
+func (p *Polymorph) modify(ctx context.Context, path Path, display string, op modifyFunc) (*Polymorph, error) {
+	m := &modifier{polymorph: p, path: path, display: display, op: op}
+	raw, trie, err := m.rewrite(ctx, p.raw, p.links, 0, nil, "")
+	if err != nil {
+		return nil, err
+	}
+	return p.edited(raw, trie), nil
+}
+
+// modifier holds the state of a single call to modify
+type modifier struct {
+	polymorph *Polymorph
+	path      Path
+	display   string
+	op        modifyFunc
+}
+
+// rewrite returns raw, which is the value at path[:index], after
+// applying the modification to it. trie records the references
+// inlined inside raw. chain and ref are the refs followed to get
+// to raw, and the last one of them.
+func (m *modifier) rewrite(ctx context.Context, raw json.RawMessage, trie *linkTrie, index int, chain []string, ref string) (json.RawMessage, *linkTrie, error) {
+	var err error
+	trie = trie.clone()
+
+	if IsRef(raw) {
+		ref, _ = AssertRef(raw)
+		raw, chain, err = m.polymorph.resolve(ctx, raw, chain)
+		if err != nil {
+			return nil, nil, m.fail(index, ref, err)
+		}
+		trie.inlined = true
+	}
+
+	c, err := decodeContainer(raw)
+	if err != nil {
+		return nil, nil, m.fail(index, ref, err)
+	}
+
+	key := m.path[index]
+	if index == len(m.path)-1 {
+		if err = m.op(c, key, trie); err != nil {
+			return nil, nil, m.fail(index, ref, err)
+		}
+	} else {
+		child, canonical, ok := c.get(key)
+		if !ok {
+			return nil, nil, m.fail(index, ref, ErrPathNotFound)
+		}
+
+		child, childTrie, err := m.rewrite(ctx, child, trie.child(canonical), index+1, chain, ref)
+		if err != nil {
+			return nil, nil, err
+		}
+		c.set(canonical, child)
+		trie.setChild(canonical, childTrie)
+	}
+
+	raw, err = c.encode()
+	if err != nil {
+		return nil, nil, m.fail(index, ref, err)
+	}
+	return raw, trie.prune(), nil
+}
+
+func (m *modifier) fail(index int, ref string, err error) error {
+	return &ResolutionError{Path: m.path, Index: index, Ref: ref, Err: err, display: m.display}
+}
+
+// container is a decoded JSON object or array
+type container struct {
+	isArray bool
+	object  map[string]json.RawMessage
+	array   []json.RawMessage
+}
+
+// decodeContainer decodes the raw JSON object or array. Any other
+// valid JSON value has no children, so it fails with ErrPathNotFound
+func decodeContainer(raw json.RawMessage) (*container, error) {
+	switch firstByte(raw) {
+	case '{':
+		c := &container{object: make(map[string]json.RawMessage)}
+		if err := json.Unmarshal(raw, &c.object); err != nil {
+			return nil, errors.Wrap(err, "Unmarshal failed")
+		}
+		return c, nil
+	case '[':
+		c := &container{isArray: true}
+		if err := json.Unmarshal(raw, &c.array); err != nil {
+			return nil, errors.Wrap(err, "Unmarshal failed")
+		}
+		return c, nil
+	}
+
+	if !json.Valid(raw) {
+		return nil, errors.New("Unmarshal failed: invalid JSON")
+	}
+	return nil, ErrPathNotFound
+}
+
+// index converts key into an index into the array. Negative
+// indexes count back from the end. If allowEnd is true, the
+// index one past the last element, and "-", are valid too.
+func (c *container) index(key string, allowEnd bool) (int, bool) {
+	length := len(c.array)
+	if key == "-" {
+		return length, allowEnd
+	}
+
+	index, err := strconv.Atoi(key)
+	if err != nil {
+		return 0, false
+	}
+	if index < 0 {
+		index += length
+	}
+	if index < 0 || index > length || (index == length && !allowEnd) {
+		return 0, false
+	}
+	return index, true
+}
+
+// get returns the value under key, and the canonical form of
+// key, which for arrays is the non negative decimal index
+func (c *container) get(key string) (json.RawMessage, string, bool) {
+	if !c.isArray {
+		value, ok := c.object[key]
+		return value, key, ok
+	}
+
+	index, ok := c.index(key, false)
+	if !ok {
+		return nil, "", false
+	}
+	return c.array[index], strconv.Itoa(index), true
+}
+
+// set stores value under the canonical key,
+// which must exist if c is an array
+func (c *container) set(canonical string, value json.RawMessage) {
+	if !c.isArray {
+		c.object[canonical] = value
+		return
+	}
+	index, _ := strconv.Atoi(canonical)
+	c.array[index] = value
+}
+
+// replace stores value under key, adding the key to objects,
+// and appending to arrays if key is one past the end, or "-".
+// Returns the canonical form of key.
+func (c *container) replace(key string, value json.RawMessage) (string, error) {
+	if !c.isArray {
+		c.object[key] = value
+		return key, nil
+	}
+
+	index, ok := c.index(key, true)
+	if !ok {
+		return "", ErrPathNotFound
+	}
+	if index == len(c.array) {
+		c.array = append(c.array, value)
+	} else {
+		c.array[index] = value
+	}
+	return strconv.Itoa(index), nil
+}
+
+// insert stores value under key, inserting it into arrays
+// before the element at key, or appending it if key is one
+// past the end, or "-". Returns the canonical form of key.
+func (c *container) insert(key string, value json.RawMessage) (string, error) {
+	if !c.isArray {
+		c.object[key] = value
+		return key, nil
+	}
+
+	index, ok := c.index(key, true)
+	if !ok {
+		return "", ErrPathNotFound
+	}
+	tail := append([]json.RawMessage{value}, c.array[index:]...)
+	c.array = append(c.array[:index:index], tail...)
+	return strconv.Itoa(index), nil
+}
+
+// remove removes the value under key, shifting the array
+// elements after it. Returns the removed value and the
+// canonical form of key.
+func (c *container) remove(key string) (json.RawMessage, string, error) {
+	value, canonical, ok := c.get(key)
+	if !ok {
+		return nil, "", ErrPathNotFound
+	}
+
+	if !c.isArray {
+		delete(c.object, canonical)
+		return value, canonical, nil
+	}
+
+	index, _ := strconv.Atoi(canonical)
+	c.array = append(c.array[:index:index], c.array[index+1:]...)
+	return value, canonical, nil
+}
+
+func (c *container) encode() (json.RawMessage, error) {
+	var buf []byte
+	var err error
+	if c.isArray {
+		buf, err = json.Marshal(c.array)
+	} else {
+		buf, err = json.Marshal(c.object)
+	}
+	if err != nil {
+		return nil, errors.Wrap(err, "Marshal failed")
+	}
+	return json.RawMessage(buf), nil
+}
+
+// linkTrie records the positions inside of a document at
+// which edits replaced IPLD references with their content.
+// Children are keyed by object key, or by array index.
+type linkTrie struct {
+	inlined  bool
+	children map[string]*linkTrie
+}
+
+// child returns the trie of the value under
+// key, which is nil if nothing was inlined there
+func (t *linkTrie) child(key string) *linkTrie {
+	if t == nil {
+		return nil
+	}
+	return t.children[key]
+}
+
+// setChild replaces the trie of the value under key
+func (t *linkTrie) setChild(key string, child *linkTrie) {
+	child = child.prune()
+	if child == nil {
+		delete(t.children, key)
+		return
+	}
+	if t.children == nil {
+		t.children = make(map[string]*linkTrie)
+	}
+	t.children[key] = child
+}
+
+// removeChild removes and returns the trie of the value under
+// key. If the value was an array element, the tries of the
+// elements after it are shifted to match their new indexes.
+func (t *linkTrie) removeChild(key string, isArray bool) *linkTrie {
+	removed := t.children[key]
+	delete(t.children, key)
+	if !isArray {
+		return removed
+	}
+
+	index, _ := strconv.Atoi(key)
+	t.shift(index, -1)
+	return removed
+}
+
+// shift moves the tries of the array
+// elements from index on by delta
+func (t *linkTrie) shift(index, delta int) {
+	shifted := make(map[string]*linkTrie, len(t.children))
+	for key, child := range t.children {
+		i, err := strconv.Atoi(key)
+		if err == nil && i >= index {
+			key = strconv.Itoa(i + delta)
+		}
+		shifted[key] = child
+	}
+	t.children = shifted
+}
+
+// clone returns a shallow copy of the trie, which can be
+// modified without affecting the original. Children are
+// shared, and must be cloned before they are modified.
+func (t *linkTrie) clone() *linkTrie {
+	cloned := &linkTrie{}
+	if t == nil {
+		return cloned
+	}
+	cloned.inlined = t.inlined
+	if len(t.children) > 0 {
+		cloned.children = make(map[string]*linkTrie, len(t.children))
+		for key, child := range t.children {
+			cloned.children[key] = child
+		}
+	}
+	return cloned
+}
+
+// prune returns nil if the trie records nothing
+func (t *linkTrie) prune() *linkTrie {
+	if t == nil || (!t.inlined && len(t.children) == 0) {
+		return nil
+	}
+	return t
+}
@@ -0,0 +1,242 @@
+package ipldpolymorph_test
+
+import (
+	"context"
+	"encoding/json"
+	"strconv"
+	"strings"
+	"testing"
+
+	ipldpolymorph "github.com/computes/go-ipld-polymorph"
+	"github.com/pkg/errors"
+)
+
+// putLinked puts every raw JSON block into store in order, replacing
+// "$0", "$1", ... in later blocks with the refs of earlier ones
+func putLinked(t *testing.T, store ipldpolymorph.BlockStore, blocks ...string) []string {
+	refs := []string{}
+	for _, block := range blocks {
+		for i, ref := range refs {
+			block = strings.Replace(block, "$"+strconv.Itoa(i), ref, -1)
+		}
+		ref, err := store.Put(context.Background(), json.RawMessage(block))
+		if err != nil {
+			t.Fatal("Could not Put:", err.Error())
+		}
+		refs = append(refs, ref)
+	}
+	return refs
+}
+
+func TestSetAcrossLinks(t *testing.T) {
+	store := ipldpolymorph.NewMemoryBlockStore()
+	refs := putLinked(t, store,
+		`{"c":"red"}`,
+		`{"b":{"/":"$0"},"sibling":{"/":"$0"}}`,
+		`{"a":{"/":"$1"}}`,
+	)
+	p := ipldpolymorph.NewWithBlockStore(store)
+	p.UnmarshalJSON([]byte(`{"/":"` + refs[2] + `"}`))
+
+	edited, err := p.Set("a/b/c", "blue")
+	if err != nil {
+		t.Fatal(`Could not Set "a/b/c":`, err.Error())
+	}
+
+	c, err := edited.GetString("a/b/c")
+	if err != nil {
+		t.Fatal(`Could not GetString for path "a/b/c":`, err.Error())
+	}
+	if c != "blue" {
+		t.Fatalf(`Expected c == "blue". Actual c == "%v"`, c)
+	}
+
+	sibling, err := edited.GetUnresolvedPolymorph("a/sibling")
+	if err != nil {
+		t.Fatal(`Could not GetUnresolvedPolymorph for path "a/sibling":`, err.Error())
+	}
+	if sibling.AsRef() != refs[0] {
+		t.Fatalf(`Expected sibling ref == "%v". Actual sibling ref == "%v"`, refs[0], sibling.AsRef())
+	}
+
+	c, err = p.GetString("a/b/c")
+	if err != nil {
+		t.Fatal(`Could not GetString for path "a/b/c":`, err.Error())
+	}
+	if c != "red" {
+		t.Fatalf(`Expected original c == "red". Actual c == "%v"`, c)
+	}
+}
+
+func TestSetArrayElement(t *testing.T) {
+	p, err := ipldpolymorph.FromInterface(ipfsURL, map[string]interface{}{
+		"list": []string{"a", "b"},
+	})
+	if err != nil {
+		t.Fatal("Could not FromInterface:", err.Error())
+	}
+
+	p, err = p.Set("list/-1", "c")
+	if err != nil {
+		t.Fatal(`Could not Set "list/-1":`, err.Error())
+	}
+	p, err = p.Set("/list/-", "d")
+	if err != nil {
+		t.Fatal(`Could not Set "/list/-":`, err.Error())
+	}
+
+	buf, err := p.MarshalJSON()
+	if err != nil {
+		t.Fatal("Could not MarshalJSON:", err.Error())
+	}
+	if string(buf) != `{"list":["a","c","d"]}` {
+		t.Fatalf(`Expected json == {"list":["a","c","d"]}. Actual json == %v`, string(buf))
+	}
+}
+
+func TestSetMissingParent(t *testing.T) {
+	p, err := ipldpolymorph.FromInterface(ipfsURL, map[string]interface{}{
+		"foo": "bar",
+	})
+	if err != nil {
+		t.Fatal("Could not FromInterface:", err.Error())
+	}
+
+	_, err = p.Set("missing/key", "value")
+	if !errors.Is(err, ipldpolymorph.ErrPathNotFound) {
+		t.Fatalf("Expected err to be ErrPathNotFound. Actual err == %v", err)
+	}
+}
+
+func TestDeleteAcrossLinks(t *testing.T) {
+	store := ipldpolymorph.NewMemoryBlockStore()
+	refs := putLinked(t, store,
+		`{"c":"red","d":"green"}`,
+		`{"b":{"/":"$0"},"sibling":{"/":"$0"}}`,
+	)
+	p := ipldpolymorph.NewWithBlockStore(store)
+	p.UnmarshalJSON([]byte(`{"/":"` + refs[1] + `"}`))
+
+	edited, err := p.Delete("b/c")
+	if err != nil {
+		t.Fatal(`Could not Delete "b/c":`, err.Error())
+	}
+
+	b, err := edited.GetRawMessage("b")
+	if err != nil {
+		t.Fatal(`Could not GetRawMessage for path "b":`, err.Error())
+	}
+	if string(b) != `{"d":"green"}` {
+		t.Fatalf(`Expected b == {"d":"green"}. Actual b == %v`, string(b))
+	}
+
+	sibling, err := edited.GetUnresolvedPolymorph("sibling")
+	if err != nil {
+		t.Fatal(`Could not GetUnresolvedPolymorph for path "sibling":`, err.Error())
+	}
+	if sibling.AsRef() != refs[0] {
+		t.Fatalf(`Expected sibling ref == "%v". Actual sibling ref == "%v"`, refs[0], sibling.AsRef())
+	}
+}
+
+func TestDeleteArrayElement(t *testing.T) {
+	store := ipldpolymorph.NewMemoryBlockStore()
+	refs := putLinked(t, store,
+		`{"name":"first"}`,
+		`{"name":"second"}`,
+		`{"steps":[{"/":"$0"},{"/":"$1"}]}`,
+	)
+	p := ipldpolymorph.NewWithBlockStore(store)
+	p.UnmarshalJSON([]byte(`{"/":"` + refs[2] + `"}`))
+
+	edited, err := p.Set("steps/1/name", "last")
+	if err != nil {
+		t.Fatal(`Could not Set "steps/1/name":`, err.Error())
+	}
+	edited, err = edited.Delete("steps/0")
+	if err != nil {
+		t.Fatal(`Could not Delete "steps/0":`, err.Error())
+	}
+
+	root, _, err := edited.Commit()
+	if err != nil {
+		t.Fatal("Could not Commit:", err.Error())
+	}
+	committed := ipldpolymorph.NewWithBlockStore(store)
+	committed.UnmarshalJSON([]byte(`{"/":"` + root + `"}`))
+
+	step, err := committed.GetUnresolvedPolymorph("steps/0")
+	if err != nil {
+		t.Fatal(`Could not GetUnresolvedPolymorph for path "steps/0":`, err.Error())
+	}
+	if !step.IsRef() {
+		t.Fatal(`Expected "steps/0" to be committed as a ref`)
+	}
+	name, err := step.GetString("name")
+	if err != nil {
+		t.Fatal(`Could not GetString for path "name":`, err.Error())
+	}
+	if name != "last" {
+		t.Fatalf(`Expected name == "last". Actual name == "%v"`, name)
+	}
+}
+
+func TestDeleteMissing(t *testing.T) {
+	p, err := ipldpolymorph.FromInterface(ipfsURL, map[string]interface{}{
+		"list": []string{"a"},
+	})
+	if err != nil {
+		t.Fatal("Could not FromInterface:", err.Error())
+	}
+
+	_, err = p.Delete("list/1")
+	if !errors.Is(err, ipldpolymorph.ErrPathNotFound) {
+		t.Fatalf("Expected err to be ErrPathNotFound. Actual err == %v", err)
+	}
+}
+
+func TestMoveAcrossLinks(t *testing.T) {
+	store := ipldpolymorph.NewMemoryBlockStore()
+	refs := putLinked(t, store,
+		`{"c":"red"}`,
+		`{"b":{"/":"$0"}}`,
+		`{"from":{"/":"$1"},"to":{}}`,
+	)
+	p := ipldpolymorph.NewWithBlockStore(store)
+	p.UnmarshalJSON([]byte(`{"/":"` + refs[2] + `"}`))
+
+	edited, err := p.Move("from/b", "to/b")
+	if err != nil {
+		t.Fatal(`Could not Move "from/b" to "to/b":`, err.Error())
+	}
+
+	b, err := edited.GetUnresolvedPolymorph("to/b")
+	if err != nil {
+		t.Fatal(`Could not GetUnresolvedPolymorph for path "to/b":`, err.Error())
+	}
+	if b.AsRef() != refs[0] {
+		t.Fatalf(`Expected b ref == "%v". Actual b ref == "%v"`, refs[0], b.AsRef())
+	}
+
+	from, err := edited.GetRawMessage("from")
+	if err != nil {
+		t.Fatal(`Could not GetRawMessage for path "from":`, err.Error())
+	}
+	if string(from) != `{}` {
+		t.Fatalf(`Expected from == {}. Actual from == %v`, string(from))
+	}
+}
+
+func TestMoveIntoChild(t *testing.T) {
+	p, err := ipldpolymorph.FromInterface(ipfsURL, map[string]interface{}{
+		"foo": map[string]string{},
+	})
+	if err != nil {
+		t.Fatal("Could not FromInterface:", err.Error())
+	}
+
+	_, err = p.Move("foo", "foo/bar")
+	if err == nil {
+		t.Fatal("Expected Move into its own child to fail")
+	}
+}
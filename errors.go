@@ -0,0 +1,124 @@
+package ipldpolymorph
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+var (
+	// ErrNotRef is returned when a value
+	// is expected to be an IPLD reference
+	// but is not
+	ErrNotRef = errors.New("not an IPLD ref")
+
+	// ErrPathNotFound is returned when
+	// there is no value at a path
+	ErrPathNotFound = errors.New("no value found")
+
+	// ErrBlockNotFound is returned by a BlockStore
+	// when it definitively does not have the
+	// requested block
+	ErrBlockNotFound = errors.New("block not found")
+
+	// ErrTypeMismatch is returned when a value
+	// can't be decoded into the requested type
+	ErrTypeMismatch = errors.New("type mismatch")
+
+	// ErrOverflow is returned when a number
+	// does not fit into the requested type
+	ErrOverflow = errors.New("number out of range")
+
+	// ErrTestFailed is returned when a JSON Patch
+	// test operation finds a different value
+	ErrTestFailed = errors.New("JSON Patch test failed")
+)
+
+// ResolutionError is returned when a value can't be
+// retrieved from a path. Err holds the reason, and is
+// matched by errors.Is and errors.As, e.g. against
+// ErrPathNotFound or ErrBlockNotFound.
+type ResolutionError struct {
+	// Path is the full path being resolved
+	Path Path
+
+	// Index is the index in Path of the segment being
+	// resolved when the failure occurred. It equals
+	// len(Path) when the value at the end of the path
+	// could not be resolved.
+	Index int
+
+	// Ref is the IPLD reference that was being fetched,
+	// or when a segment was not found, the ref of the
+	// block it was missing from. It is empty if no
+	// reference was involved.
+	Ref string
+
+	// Err is the underlying error
+	Err error
+
+	// display is the path as the caller wrote it
+	display string
+}
+
+func (e *ResolutionError) Error() string {
+	display := e.display
+	if display == "" {
+		display = e.Path.String()
+	}
+
+	message := fmt.Sprintf(`%v at path "%v"`, e.Err, display)
+	if e.Ref != "" {
+		message += fmt.Sprintf(` (ref "%v")`, e.Ref)
+	}
+	return message
+}
+
+// Unwrap returns the underlying error
+func (e *ResolutionError) Unwrap() error {
+	return e.Err
+}
+
+// Cause returns the underlying error,
+// for use with errors.Cause
+func (e *ResolutionError) Cause() error {
+	return e.Err
+}
+
+// StatusError is returned by IPFSBlockStore when the
+// IPFS API responds with an unexpected status code
+type StatusError struct {
+	StatusCode int
+	Message    string
+}
+
+func (e *StatusError) Error() string {
+	return fmt.Sprintf("IPFS API returned status code %v: %v", e.StatusCode, e.Message)
+}
+
+// LinkChainError is returned when resolving IPLD references
+// either loops back to a ref that is already on the current
+// chain of references, or follows more references than the
+// configured maximum number of link hops.
+type LinkChainError struct {
+	// Chain holds the refs that were followed, in order,
+	// ending with the ref that could not be followed
+	Chain []string
+
+	// Cycle is true if the last ref in Chain was
+	// already followed earlier in the Chain
+	Cycle bool
+
+	// MaxLinkHops is the maximum number of link hops
+	// that was in effect
+	MaxLinkHops int
+}
+
+func (e *LinkChainError) Error() string {
+	chain := strings.Join(e.Chain, " -> ")
+	if e.Cycle {
+		return fmt.Sprintf("IPLD reference cycle detected: %v", chain)
+	}
+	return fmt.Sprintf("exceeded maximum of %v IPLD link hops: %v", e.MaxLinkHops, chain)
+}
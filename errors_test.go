@@ -0,0 +1,124 @@
+package ipldpolymorph_test
+
+import (
+	"encoding/json"
+	"net/http"
+	"reflect"
+	"testing"
+
+	ipldpolymorph "github.com/computes/go-ipld-polymorph"
+	"github.com/pkg/errors"
+)
+
+func TestErrPathNotFound(t *testing.T) {
+	beforeEach()
+	httpResponses[http.MethodGet]["/api/v0/dag/get?arg=foo-addr"] = `{"bar": {"red": 1}}`
+	p := ipldpolymorph.New(ipfsURL)
+	p.UnmarshalJSON([]byte(`{"foo": {"/": "foo-addr"}}`))
+
+	_, err := p.GetString("foo/bar/blue")
+	if !errors.Is(err, ipldpolymorph.ErrPathNotFound) {
+		t.Fatal("Expected GetString to return ErrPathNotFound, received", err)
+	}
+
+	var resolutionErr *ipldpolymorph.ResolutionError
+	if !errors.As(err, &resolutionErr) {
+		t.Fatal("Expected GetString to return a ResolutionError, received", err)
+	}
+	expected := ipldpolymorph.Path{"foo", "bar", "blue"}
+	if !reflect.DeepEqual(resolutionErr.Path, expected) {
+		t.Errorf(`Expected Path == %#v. Actual Path == %#v`, expected, resolutionErr.Path)
+	}
+	if resolutionErr.Index != 2 {
+		t.Errorf(`Expected Index == 2. Actual Index == %v`, resolutionErr.Index)
+	}
+	if resolutionErr.Ref != "foo-addr" {
+		t.Errorf(`Expected Ref == "foo-addr". Actual Ref == "%v"`, resolutionErr.Ref)
+	}
+}
+
+func TestErrPathNotFoundArray(t *testing.T) {
+	beforeEach()
+	p := ipldpolymorph.New(ipfsURL)
+	p.UnmarshalJSON([]byte(`{"foo": [1, 2]}`))
+
+	_, err := p.GetRawMessage("foo/bar")
+	if !errors.Is(err, ipldpolymorph.ErrPathNotFound) {
+		t.Fatal("Expected GetRawMessage to return ErrPathNotFound, received", err)
+	}
+}
+
+func TestErrBlockNotFound(t *testing.T) {
+	beforeEach()
+	p := ipldpolymorph.New(ipfsURL)
+	p.UnmarshalJSON([]byte(`{"foo": {"bar": {"/": "bar-addr"}}}`))
+
+	_, err := p.GetString("foo/bar")
+	if !errors.Is(err, ipldpolymorph.ErrBlockNotFound) {
+		t.Fatal("Expected GetString to return ErrBlockNotFound, received", err)
+	}
+	if errors.Is(err, ipldpolymorph.ErrPathNotFound) {
+		t.Fatal("Expected GetString not to return ErrPathNotFound, received", err)
+	}
+
+	var resolutionErr *ipldpolymorph.ResolutionError
+	if !errors.As(err, &resolutionErr) {
+		t.Fatal("Expected GetString to return a ResolutionError, received", err)
+	}
+	if resolutionErr.Index != 2 {
+		t.Errorf(`Expected Index == 2. Actual Index == %v`, resolutionErr.Index)
+	}
+	if resolutionErr.Ref != "bar-addr" {
+		t.Errorf(`Expected Ref == "bar-addr". Actual Ref == "%v"`, resolutionErr.Ref)
+	}
+}
+
+func TestErrTypeMismatch(t *testing.T) {
+	beforeEach()
+	p := ipldpolymorph.New(ipfsURL)
+	p.UnmarshalJSON([]byte(`{"foo": 2}`))
+
+	_, err := p.GetString("foo")
+	if !errors.Is(err, ipldpolymorph.ErrTypeMismatch) {
+		t.Fatal("Expected GetString to return ErrTypeMismatch, received", err)
+	}
+}
+
+func TestErrTypeMismatchBadJSON(t *testing.T) {
+	beforeEach()
+	p := ipldpolymorph.New(ipfsURL)
+	p.UnmarshalJSON([]byte(`"ba`))
+
+	_, err := p.AsString()
+	if errors.Is(err, ipldpolymorph.ErrTypeMismatch) {
+		t.Fatal("Expected AsString not to return ErrTypeMismatch, received", err)
+	}
+}
+
+func TestErrNotRef(t *testing.T) {
+	_, err := ipldpolymorph.AssertRef(json.RawMessage(`{"/": "foo", "bar": 1}`))
+	if !errors.Is(err, ipldpolymorph.ErrNotRef) {
+		t.Fatal("Expected AssertRef to return ErrNotRef, received", err)
+	}
+
+	_, err = ipldpolymorph.ResolveRef(ipfsURL, json.RawMessage(`"foo"`), ipldpolymorph.NewSimpleCache())
+	if !errors.Is(err, ipldpolymorph.ErrNotRef) {
+		t.Fatal("Expected ResolveRef to return ErrNotRef, received", err)
+	}
+}
+
+func TestLinkChainErrorAs(t *testing.T) {
+	beforeEach()
+	httpResponses[http.MethodGet]["/api/v0/dag/get?arg=a"] = `{"/": "a"}`
+	p := ipldpolymorph.New(ipfsURL)
+	p.UnmarshalJSON([]byte(`{"foo": {"/": "a"}}`))
+
+	_, err := p.GetString("foo")
+	var chainErr *ipldpolymorph.LinkChainError
+	if !errors.As(err, &chainErr) {
+		t.Fatal("Expected GetString to return a LinkChainError, received", err)
+	}
+	if !chainErr.Cycle {
+		t.Fatal("Expected chainErr.Cycle to be true, was false")
+	}
+}
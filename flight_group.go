@@ -0,0 +1,130 @@
+package ipldpolymorph
+
+import (
+	"context"
+	"encoding/json"
+	"reflect"
+	"sync"
+
+	"github.com/pkg/errors"
+)
+
+// blockFetches coalesces concurrent fetches of the same
+// ref from the same BlockStore across the whole package
+var blockFetches = &flightGroup{}
+
+// flightGroup deduplicates concurrent calls for the same
+// key, so that only one of them does the work and every
+// other caller waits for, and shares, its result.
+type flightGroup struct {
+	mutex sync.Mutex
+	calls map[flightKey]*flightCall
+}
+
+type flightKey struct {
+	store interface{}
+	ref   string
+}
+
+// keyedBlockStore is implemented by BlockStores whose
+// identity for the purpose of coalescing fetches is not
+// the BlockStore value itself, e.g. because instances
+// are cheap to create and talk to the same backend.
+type keyedBlockStore interface {
+	blockStoreKey() interface{}
+}
+
+// blockStoreKey returns a comparable value identifying
+// store, or nil if there is none. Only pointers are used as
+// they are: other comparable types, e.g. structs, may still
+// hold maps or slices in interface fields, which panic
+// when hashed.
+func blockStoreKey(store BlockStore) interface{} {
+	if keyed, ok := store.(keyedBlockStore); ok {
+		return keyed.blockStoreKey()
+	}
+	if reflect.TypeOf(store).Kind() == reflect.Ptr {
+		return store
+	}
+	return nil
+}
+
+// errFetchPanicked is shared with the callers waiting
+// on a fetch that panicked
+var errFetchPanicked = errors.New("fetch panicked")
+
+type flightCall struct {
+	done  chan struct{}
+	value json.RawMessage
+	err   error
+}
+
+// get returns the block at ref in store by calling fetch,
+// unless the same block is already being fetched, in which
+// case it waits for that fetch to complete instead. Waiters
+// stop waiting when their own ctx is done. If the fetch they
+// waited on was aborted by its caller's ctx, they try again.
+func (g *flightGroup) get(ctx context.Context, store BlockStore, ref string, fetch func() (json.RawMessage, error)) (json.RawMessage, error) {
+	storeKey := blockStoreKey(store)
+	if storeKey == nil {
+		// store can't be used as a map key
+		return fetch()
+	}
+	key := flightKey{store: storeKey, ref: ref}
+
+	for {
+		call, leader := g.join(key)
+		if leader {
+			g.run(key, call, fetch)
+			return call.value, call.err
+		}
+
+		select {
+		case <-call.done:
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+
+		if isContextError(call.err) && ctx.Err() == nil {
+			continue
+		}
+		return call.value, call.err
+	}
+}
+
+// join returns the call in flight for key, or registers a new
+// one, in which case the boolean is true and the caller must
+// run it
+func (g *flightGroup) join(key flightKey) (*flightCall, bool) {
+	g.mutex.Lock()
+	defer g.mutex.Unlock()
+
+	if g.calls == nil {
+		g.calls = make(map[flightKey]*flightCall)
+	}
+	if call, ok := g.calls[key]; ok {
+		return call, false
+	}
+	call := &flightCall{done: make(chan struct{})}
+	g.calls[key] = call
+	return call, true
+}
+
+// run stores the result of fetch in call, then releases
+// its waiters, even if fetch panics
+func (g *flightGroup) run(key flightKey, call *flightCall, fetch func() (json.RawMessage, error)) {
+	call.err = errFetchPanicked
+	defer func() {
+		g.mutex.Lock()
+		delete(g.calls, key)
+		g.mutex.Unlock()
+		close(call.done)
+	}()
+
+	call.value, call.err = fetch()
+}
+
+func isContextError(err error) bool {
+	cause := errors.Cause(err)
+	return cause == context.Canceled || cause == context.DeadlineExceeded
+}
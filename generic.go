@@ -0,0 +1,75 @@
+package ipldpolymorph
+
+import (
+	"context"
+
+	"github.com/pkg/errors"
+)
+
+// Get returns the value at path decoded into a T, resolving
+// IPLD references if necessary to get there.
+func Get[T any](p *Polymorph, path string) (T, error) {
+	return GetContext[T](context.Background(), p, path)
+}
+
+// GetContext returns the value at path decoded into a T, resolving
+// IPLD references if necessary to get there. See AsContext for
+// how the value is decoded.
+func GetContext[T any](ctx context.Context, p *Polymorph, path string) (T, error) {
+	poly, err := p.GetPolymorphContext(ctx, path)
+	if err != nil {
+		var zero T
+		return zero, errors.Wrap(err, "GetPolymorph failed")
+	}
+
+	return AsContext[T](ctx, poly)
+}
+
+// GetOr returns the value at path decoded into a T, or def if
+// there is no value at path. See GetOrContext for details.
+func GetOr[T any](p *Polymorph, path string, def T) (T, error) {
+	return GetOrContext(context.Background(), p, path, def)
+}
+
+// GetOrContext returns the value at path decoded into a T, or def
+// if there is no value at path. Only a missing path results in def,
+// every other failure, e.g. an IPLD reference that can't be fetched
+// or a value that can't be decoded, is returned as an error.
+func GetOrContext[T any](ctx context.Context, p *Polymorph, path string, def T) (T, error) {
+	poly, err := p.GetPolymorphContext(ctx, path)
+	if errors.Is(err, ErrPathNotFound) {
+		return def, nil
+	}
+	if err != nil {
+		var zero T
+		return zero, errors.Wrap(err, "GetPolymorph failed")
+	}
+
+	return AsContext[T](ctx, poly)
+}
+
+// As returns the current value decoded into a T,
+// resolving the IPLD reference if necessary
+func As[T any](p *Polymorph) (T, error) {
+	return AsContext[T](context.Background(), p)
+}
+
+// AsContext returns the current value decoded into a T, resolving
+// the IPLD reference if necessary. The value is decoded like
+// ToInterface does, except that a *Polymorph is returned as is,
+// sharing the configuration of p. Returns an error wrapping
+// ErrTypeMismatch if the value can't be stored in a T.
+func AsContext[T any](ctx context.Context, p *Polymorph) (T, error) {
+	var value T
+	if poly, ok := any(&value).(**Polymorph); ok {
+		raw, err := p.AsRawMessageContext(ctx)
+		if err != nil {
+			return value, errors.Wrap(err, "AsRawMessage failed")
+		}
+		*poly = p.derive(raw)
+		return value, nil
+	}
+
+	err := p.ToInterfaceContext(ctx, &value)
+	return value, err
+}
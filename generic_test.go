@@ -0,0 +1,80 @@
+package ipldpolymorph_test
+
+import (
+	"net/http"
+	"testing"
+
+	ipldpolymorph "github.com/computes/go-ipld-polymorph"
+	"github.com/pkg/errors"
+)
+
+type job struct {
+	Name  string   `json:"name"`
+	Steps []string `json:"steps"`
+}
+
+func TestGet(t *testing.T) {
+	beforeEach()
+	httpResponses[http.MethodGet]["/api/v0/dag/get?arg=foo"] = `{"job":{"/":"job"}}`
+	httpResponses[http.MethodGet]["/api/v0/dag/get?arg=job"] = `{"name":"build","steps":["compile","test"]}`
+	p := ipldpolymorph.FromRef(ipfsURL, "foo")
+
+	value, err := ipldpolymorph.Get[job](p, "job")
+	if err != nil {
+		t.Fatal(`Could not Get for path "job":`, err.Error())
+	}
+	if value.Name != "build" || len(value.Steps) != 2 {
+		t.Fatalf(`Expected job == {build [compile test]}. Actual job == %v`, value)
+	}
+
+	_, err = ipldpolymorph.Get[int](p, "job/name")
+	if !errors.Is(err, ipldpolymorph.ErrTypeMismatch) {
+		t.Fatalf("Expected err to be ErrTypeMismatch. Actual err == %v", err)
+	}
+}
+
+func TestGetOr(t *testing.T) {
+	beforeEach()
+	httpResponses[http.MethodGet]["/api/v0/dag/get?arg=foo"] = `{"present":"value","broken":{"/":"missing"}}`
+	p := ipldpolymorph.FromRef(ipfsURL, "foo")
+
+	present, err := ipldpolymorph.GetOr(p, "present", "default")
+	if err != nil {
+		t.Fatal(`Could not GetOr for path "present":`, err.Error())
+	}
+	if present != "value" {
+		t.Fatalf(`Expected present == "value". Actual present == "%v"`, present)
+	}
+
+	absent, err := ipldpolymorph.GetOr(p, "absent", "default")
+	if err != nil {
+		t.Fatal(`Could not GetOr for path "absent":`, err.Error())
+	}
+	if absent != "default" {
+		t.Fatalf(`Expected absent == "default". Actual absent == "%v"`, absent)
+	}
+
+	_, err = ipldpolymorph.GetOr(p, "broken", "default")
+	if err == nil {
+		t.Fatal(`Expected GetOr for path "broken" to fail`)
+	}
+}
+
+func TestAsPolymorph(t *testing.T) {
+	beforeEach()
+	httpResponses[http.MethodGet]["/api/v0/dag/get?arg=foo"] = `{"bar":{"/":"bar"}}`
+	httpResponses[http.MethodGet]["/api/v0/dag/get?arg=bar"] = `"red"`
+	p := ipldpolymorph.FromRef(ipfsURL, "foo")
+
+	poly, err := ipldpolymorph.As[*ipldpolymorph.Polymorph](p)
+	if err != nil {
+		t.Fatal("Could not As:", err.Error())
+	}
+	bar, err := poly.GetString("bar")
+	if err != nil {
+		t.Fatal(`Could not GetString for path "bar":`, err.Error())
+	}
+	if bar != "red" {
+		t.Fatalf(`Expected bar == "red". Actual bar == "%v"`, bar)
+	}
+}
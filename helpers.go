@@ -1,15 +1,31 @@
 package ipldpolymorph
 
 import (
+	"bytes"
+	"context"
 	"encoding/json"
 	"net/url"
+	"strconv"
 
-	"github.com/computes/ipfs-http-api/dag"
 	"github.com/pkg/errors"
 )
 
 // ResolveRef will resolve the given IPLD reference.
 func ResolveRef(ipfsURL *url.URL, raw json.RawMessage, cache Cache) (json.RawMessage, error) {
+	return ResolveRefContext(context.Background(), ipfsURL, raw, cache)
+}
+
+// ResolveRefContext will resolve the given IPLD reference,
+// aborting the request to IPFS if ctx is cancelled. The
+// request is made as configured by DefaultClient.
+func ResolveRefContext(ctx context.Context, ipfsURL *url.URL, raw json.RawMessage, cache Cache) (json.RawMessage, error) {
+	return ResolveRefWithStore(ctx, DefaultClient.blockStore(ipfsURL), raw, cache)
+}
+
+// ResolveRefWithStore will resolve the given IPLD
+// reference by retrieving it from store. Concurrent
+// calls for the same ref and store share a single Get.
+func ResolveRefWithStore(ctx context.Context, store BlockStore, raw json.RawMessage, cache Cache) (json.RawMessage, error) {
 	if raw == nil {
 		return nil, errors.Errorf("Message is nil")
 	}
@@ -18,20 +34,57 @@ func ResolveRef(ipfsURL *url.URL, raw json.RawMessage, cache Cache) (json.RawMes
 		return nil, errors.Wrap(err, "Unable to AssertRef")
 	}
 
+	return getBlock(ctx, store, ref, cache)
+}
+
+// getBlock returns the block stored at ref, consulting
+// the cache before retrieving it from store
+func getBlock(ctx context.Context, store BlockStore, ref string, cache Cache) (json.RawMessage, error) {
 	if value := cache.Get(ref); value != nil {
 		return value, nil
 	}
 
-	res, err := dag.GetBytes(ipfsURL, ref)
+	value, err := blockFetches.get(ctx, store, ref, func() (json.RawMessage, error) {
+		return store.Get(ctx, ref)
+	})
 	if err != nil {
-		return nil, errors.Wrap(err, "unable to GetBytes")
+		return nil, errors.Wrap(err, "unable to Get from BlockStore")
 	}
 
-	value := json.RawMessage(res)
 	cache.Set(ref, value)
 	return value, nil
 }
 
+// followRefs resolves raw until it is no longer an IPLD reference.
+// chain holds the refs that were followed to get to raw, every ref
+// followed is appended to a copy of it. Returns a *LinkChainError if
+// a ref is already on the chain, or if following it would make the
+// chain longer than maxLinkHops.
+func followRefs(ctx context.Context, store BlockStore, cache Cache, raw json.RawMessage, chain []string, maxLinkHops int) (json.RawMessage, []string, error) {
+	for IsRef(raw) {
+		ref, _ := AssertRef(raw)
+		// cap the capacity so appending never clobbers a
+		// sibling branch sharing the same backing array
+		chain = append(chain[:len(chain):len(chain)], ref)
+
+		for _, previous := range chain[:len(chain)-1] {
+			if previous == ref {
+				return nil, nil, &LinkChainError{Chain: chain, Cycle: true, MaxLinkHops: maxLinkHops}
+			}
+		}
+		if len(chain) > maxLinkHops {
+			return nil, nil, &LinkChainError{Chain: chain, MaxLinkHops: maxLinkHops}
+		}
+
+		var err error
+		raw, err = ResolveRefWithStore(ctx, store, raw, cache)
+		if err != nil {
+			return nil, nil, err
+		}
+	}
+	return raw, chain, nil
+}
+
 // IsRef detects if a rawMessage is an IPLD reference.
 // An IPLD reference MUST be a JSON object with ONLY
 // the key "/". The value pointed to by "/" must be a
@@ -47,29 +100,30 @@ func IsRef(raw json.RawMessage) bool {
 }
 
 // AssertRef verifies that the raw JSON object is a ref.
-// It returns the address if it is, an error if it is not.
+// It returns the address if it is, an error wrapping
+// ErrNotRef if it is not.
 func AssertRef(raw json.RawMessage) (string, error) {
 	if raw == nil {
-		return "", errors.Errorf("Polymorph.raw is nil")
+		return "", errors.Wrap(ErrNotRef, "Polymorph.raw is nil")
 	}
 	ref := map[string]json.RawMessage{}
 	err := json.Unmarshal(raw, &ref)
 	if err != nil {
-		return "", errors.Wrap(err, "Unable to Unmarshal")
+		return "", errors.Wrapf(ErrNotRef, "Unable to Unmarshal: %v", err)
 	}
 	if len(ref) > 1 {
-		return "", errors.Errorf("an IPLD ref may have only one key, found: %v", len(ref))
+		return "", errors.Wrapf(ErrNotRef, "an IPLD ref may have only one key, found: %v", len(ref))
 	}
 
 	rawAddress, ok := ref["/"]
 	if !ok {
-		return "", errors.New(`an IPLD ref must have the key "/", it was not found`)
+		return "", errors.Wrap(ErrNotRef, `an IPLD ref must have the key "/", it was not found`)
 	}
 
 	address := ""
 	err = json.Unmarshal(rawAddress, &address)
 	if err != nil {
-		return "", errors.Wrap(err, "Unable to Unmarshal")
+		return "", errors.Wrapf(ErrNotRef, "Unable to Unmarshal: %v", err)
 	}
 
 	return address, nil
@@ -78,6 +132,19 @@ func AssertRef(raw json.RawMessage) (string, error) {
 // CalcRef uploads the raw JSON to IPFS
 // and returns the new ref
 func CalcRef(ipfsURL *url.URL, raw json.Marshaler) (string, error) {
+	return CalcRefContext(context.Background(), ipfsURL, raw)
+}
+
+// CalcRefContext uploads the raw JSON to IPFS and returns the
+// new ref, aborting the request to IPFS if ctx is cancelled.
+// The request is made as configured by DefaultClient.
+func CalcRefContext(ctx context.Context, ipfsURL *url.URL, raw json.Marshaler) (string, error) {
+	return CalcRefWithStore(ctx, DefaultClient.blockStore(ipfsURL), raw)
+}
+
+// CalcRefWithStore puts the raw JSON into
+// store and returns the new ref
+func CalcRefWithStore(ctx context.Context, store BlockStore, raw json.Marshaler) (string, error) {
 	if raw == nil {
 		return "", errors.Errorf("Polymorph.raw is nil")
 	}
@@ -86,5 +153,59 @@ func CalcRef(ipfsURL *url.URL, raw json.Marshaler) (string, error) {
 		return "", errors.Wrap(err, "Unable to MarshalJSON from RawMessage")
 	}
 
-	return dag.PutBytes(ipfsURL, buf)
+	return store.Put(ctx, buf)
+}
+
+// lookupChild returns the value stored under key in the raw
+// JSON object or array. Array elements are addressed by their
+// decimal index, negative indexes count back from the end.
+// The boolean is false if there is no value under key, which
+// includes keys that aren't an index into an array, and any
+// key into a value that is neither an object nor an array.
+func lookupChild(raw json.RawMessage, key string) (json.RawMessage, bool, error) {
+	switch firstByte(raw) {
+	case '[':
+		var parsed []json.RawMessage
+		err := json.Unmarshal(raw, &parsed)
+		if err != nil {
+			return nil, false, errors.Wrap(err, "Unmarshal failed")
+		}
+
+		index, err := strconv.Atoi(key)
+		if err != nil {
+			return nil, false, nil
+		}
+		if index < 0 {
+			index += len(parsed)
+		}
+		if index < 0 || index >= len(parsed) {
+			return nil, false, nil
+		}
+		return parsed[index], true, nil
+	case '{':
+		parsed := make(map[string]json.RawMessage)
+		err := json.Unmarshal(raw, &parsed)
+		if err != nil {
+			return nil, false, errors.Wrap(err, "Unmarshal failed")
+		}
+
+		value, ok := parsed[key]
+		return value, ok, nil
+	}
+
+	if !json.Valid(raw) {
+		return nil, false, errors.New("Unmarshal failed: invalid JSON")
+	}
+	return nil, false, nil
+}
+
+// firstByte returns the first non whitespace byte of the
+// raw JSON, which identifies objects ('{') and arrays ('[').
+// Returns 0 if raw is empty.
+func firstByte(raw json.RawMessage) byte {
+	trimmed := bytes.TrimLeft(raw, " \t\r\n")
+	if len(trimmed) == 0 {
+		return 0
+	}
+	return trimmed[0]
 }
@@ -1,9 +1,13 @@
 package ipldpolymorph_test
 
 import (
+	"context"
 	"encoding/json"
 	"net/http"
+	"sync"
+	"sync/atomic"
 	"testing"
+	"time"
 
 	ipldpolymorph "github.com/computes/go-ipld-polymorph"
 )
@@ -96,3 +100,238 @@ func TestResolveRefNotFound(t *testing.T) {
 		t.Error("Expected ResolveRef to return a nil response, received", res)
 	}
 }
+
+func TestResolveRefContextCancelled(t *testing.T) {
+	beforeEach()
+	httpResponses[http.MethodGet]["/api/v0/dag/get?arg=foo"] = `"bar"`
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	res, err := ipldpolymorph.ResolveRefContext(ctx, ipfsURL, json.RawMessage([]byte(`{"/":"foo"}`)), ipldpolymorph.NewSimpleCache())
+	if err == nil {
+		t.Error("Expected ResolveRefContext to return an error, received nil")
+	}
+	if res != nil {
+		t.Error("Expected ResolveRefContext to return a nil response, received", res)
+	}
+}
+
+func TestCalcRef(t *testing.T) {
+	beforeEach()
+	httpResponses[http.MethodPost]["/api/v0/dag/put?"] = `{"Cid":{"/":"foo"}}`
+
+	ref, err := ipldpolymorph.CalcRef(ipfsURL, json.RawMessage([]byte(`"bar"`)))
+	if err != nil {
+		t.Fatal("Failed to CalcRef:", err.Error())
+	}
+	if ref != "foo" {
+		t.Errorf(`Expected ref == "foo". Actual ref == "%v"`, ref)
+	}
+}
+
+func TestCalcRefContextCancelled(t *testing.T) {
+	beforeEach()
+	httpResponses[http.MethodPost]["/api/v0/dag/put?"] = `{"Cid":{"/":"foo"}}`
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	ref, err := ipldpolymorph.CalcRefContext(ctx, ipfsURL, json.RawMessage([]byte(`"bar"`)))
+	if err == nil {
+		t.Error("Expected CalcRefContext to return an error, received nil")
+	}
+	if ref != "" {
+		t.Errorf(`Expected ref == "". Actual ref == "%v"`, ref)
+	}
+}
+
+// blockingBlockStore counts Gets and blocks
+// every Get until release is closed
+type blockingBlockStore struct {
+	ipldpolymorph.BlockStore
+	gets    int32
+	release chan struct{}
+}
+
+func (s *blockingBlockStore) Get(ctx context.Context, ref string) (json.RawMessage, error) {
+	atomic.AddInt32(&s.gets, 1)
+	<-s.release
+	return s.BlockStore.Get(ctx, ref)
+}
+
+func TestResolveRefWithStoreConcurrent(t *testing.T) {
+	memory := ipldpolymorph.NewMemoryBlockStore()
+	ref, err := memory.Put(context.Background(), json.RawMessage(`"bar"`))
+	if err != nil {
+		t.Fatal("Could not Put:", err.Error())
+	}
+	store := &blockingBlockStore{BlockStore: memory, release: make(chan struct{})}
+	raw := json.RawMessage(`{"/":"` + ref + `"}`)
+
+	var wg sync.WaitGroup
+	results := make([]json.RawMessage, 10)
+	errs := make([]error, 10)
+	for i := range results {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			results[i], errs[i] = ipldpolymorph.ResolveRefWithStore(context.Background(), store, raw, ipldpolymorph.NewSimpleCache())
+		}(i)
+	}
+
+	for atomic.LoadInt32(&store.gets) == 0 {
+		time.Sleep(time.Millisecond)
+	}
+	time.Sleep(50 * time.Millisecond)
+	close(store.release)
+	wg.Wait()
+
+	if gets := atomic.LoadInt32(&store.gets); gets != 1 {
+		t.Fatalf(`Expected gets == 1. Actual gets == %v`, gets)
+	}
+	for i := range results {
+		if errs[i] != nil {
+			t.Fatal("Failed to ResolveRefWithStore:", errs[i].Error())
+		}
+		if string(results[i]) != `"bar"` {
+			t.Fatalf(`Expected results[%v] == "bar". Actual results[%v] == %s`, i, i, results[i])
+		}
+	}
+}
+
+func TestResolveRefWithStoreConcurrentWaiterCancelled(t *testing.T) {
+	memory := ipldpolymorph.NewMemoryBlockStore()
+	ref, err := memory.Put(context.Background(), json.RawMessage(`"bar"`))
+	if err != nil {
+		t.Fatal("Could not Put:", err.Error())
+	}
+	store := &blockingBlockStore{BlockStore: memory, release: make(chan struct{})}
+	defer close(store.release)
+	raw := json.RawMessage(`{"/":"` + ref + `"}`)
+
+	go ipldpolymorph.ResolveRefWithStore(context.Background(), store, raw, ipldpolymorph.NewSimpleCache())
+	for atomic.LoadInt32(&store.gets) == 0 {
+		time.Sleep(time.Millisecond)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	res, err := ipldpolymorph.ResolveRefWithStore(ctx, store, raw, ipldpolymorph.NewSimpleCache())
+	if err == nil {
+		t.Error("Expected ResolveRefWithStore to return an error, received nil")
+	}
+	if res != nil {
+		t.Error("Expected ResolveRefWithStore to return a nil response, received", res)
+	}
+}
+
+// valueBlockStore is a comparable store
+// that panics when used as a map key
+type valueBlockStore struct {
+	blocks interface{}
+}
+
+func (s valueBlockStore) Get(ctx context.Context, ref string) (json.RawMessage, error) {
+	return s.blocks.(map[string]json.RawMessage)[ref], nil
+}
+
+func (s valueBlockStore) Put(ctx context.Context, raw json.RawMessage) (string, error) {
+	return "", nil
+}
+
+func TestResolveRefWithStoreUnhashable(t *testing.T) {
+	store := valueBlockStore{blocks: map[string]json.RawMessage{"foo": json.RawMessage(`"bar"`)}}
+	raw := json.RawMessage(`{"/":"foo"}`)
+
+	for i := 0; i < 2; i++ {
+		res, err := ipldpolymorph.ResolveRefWithStore(context.Background(), store, raw, ipldpolymorph.NewSimpleCache())
+		if err != nil {
+			t.Fatal("Failed to ResolveRefWithStore:", err.Error())
+		}
+		if string(res) != `"bar"` {
+			t.Fatalf(`Expected res == "bar". Actual res == %s`, res)
+		}
+	}
+}
+
+// panickingBlockStore counts Gets, and panics in
+// every Get once release is closed
+type panickingBlockStore struct {
+	ipldpolymorph.BlockStore
+	gets    int32
+	release chan struct{}
+}
+
+func (s *panickingBlockStore) Get(ctx context.Context, ref string) (json.RawMessage, error) {
+	atomic.AddInt32(&s.gets, 1)
+	<-s.release
+	panic("Get panicked")
+}
+
+func TestResolveRefWithStoreConcurrentPanic(t *testing.T) {
+	store := &panickingBlockStore{release: make(chan struct{})}
+	raw := json.RawMessage(`{"/":"foo"}`)
+
+	recovered := make(chan interface{})
+	go func() {
+		defer func() { recovered <- recover() }()
+		ipldpolymorph.ResolveRefWithStore(context.Background(), store, raw, ipldpolymorph.NewSimpleCache())
+	}()
+	for atomic.LoadInt32(&store.gets) == 0 {
+		time.Sleep(time.Millisecond)
+	}
+
+	errs := make(chan error)
+	go func() {
+		_, err := ipldpolymorph.ResolveRefWithStore(context.Background(), store, raw, ipldpolymorph.NewSimpleCache())
+		errs <- err
+	}()
+	time.Sleep(50 * time.Millisecond)
+	close(store.release)
+
+	if r := <-recovered; r == nil {
+		t.Error("Expected ResolveRefWithStore to panic")
+	}
+	select {
+	case err := <-errs:
+		if err == nil {
+			t.Error("Expected ResolveRefWithStore to return an error, received nil")
+		}
+	case <-time.After(time.Second):
+		t.Fatal("Expected ResolveRefWithStore to return after the fetch panicked")
+	}
+	if gets := atomic.LoadInt32(&store.gets); gets != 1 {
+		t.Fatalf(`Expected gets == 1. Actual gets == %v`, gets)
+	}
+}
+
+func TestResolveRefConcurrent(t *testing.T) {
+	beforeEach()
+	httpDelay = 50 * time.Millisecond
+	httpResponses[http.MethodGet]["/api/v0/dag/get?arg=foo"] = `"bar"`
+
+	var wg sync.WaitGroup
+	results := make([]string, 20)
+	errs := make([]error, 20)
+	for i := range results {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			results[i], errs[i] = ipldpolymorph.FromRef(ipfsURL, "foo").AsString()
+		}(i)
+	}
+	wg.Wait()
+
+	if requests := countRequests(http.MethodGet, "/api/v0/dag/get?arg=foo"); requests != 1 {
+		t.Fatalf(`Expected requests == 1. Actual requests == %v`, requests)
+	}
+	for i := range results {
+		if errs[i] != nil {
+			t.Fatal("Failed to AsString:", errs[i].Error())
+		}
+		if results[i] != "bar" {
+			t.Fatalf(`Expected results[%v] == "bar". Actual results[%v] == "%v"`, i, i, results[i])
+		}
+	}
+}
@@ -0,0 +1,160 @@
+package ipldpolymorph
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"io/ioutil"
+	"mime/multipart"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// IPFSBlockStore implements BlockStore
+// using the IPFS HTTP dag API. Requests
+// are made with HTTPClient, or with
+// http.DefaultClient if it is nil.
+type IPFSBlockStore struct {
+	URL        *url.URL
+	HTTPClient *http.Client
+}
+
+// NewIPFSBlockStore returns an instance of IPFSBlockStore
+// talking to the IPFS API at ipfsURL, which can be used
+// as BlockStore
+func NewIPFSBlockStore(ipfsURL *url.URL) BlockStore {
+	return &IPFSBlockStore{URL: ipfsURL}
+}
+
+// Get retrieves the raw JSON stored at ref from the
+// IPFS dag API. The request is bound to ctx, so cancelling
+// ctx aborts the HTTP request. Returns ErrBlockNotFound if
+// IPFS reports that the block does not exist, and a
+// *StatusError for any other unexpected response.
+func (s *IPFSBlockStore) Get(ctx context.Context, ref string) (json.RawMessage, error) {
+	if s.URL == nil {
+		return nil, errors.New("IPFS URL is nil")
+	}
+	getURL := s.URL.ResolveReference(&url.URL{
+		Path:     "api/v0/dag/get",
+		RawQuery: url.Values{"arg": []string{ref}}.Encode(),
+	})
+
+	req, err := http.NewRequest(http.MethodGet, getURL.String(), nil)
+	if err != nil {
+		return nil, errors.Wrap(err, "Unable to NewRequest")
+	}
+
+	res, err := s.httpClient().Do(req.WithContext(ctx))
+	if err != nil {
+		return nil, errors.Wrap(err, "Unable to Get")
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode != http.StatusOK {
+		statusErr := readStatusError(res)
+		// IPFS answers requests for unknown blocks with either
+		// a 404 or a 500 whose message says it was not found
+		if statusErr.StatusCode == http.StatusNotFound || strings.Contains(statusErr.Message, "not found") {
+			return nil, errors.Wrap(ErrBlockNotFound, statusErr.Message)
+		}
+		return nil, errors.Wrap(statusErr, "dag get failed")
+	}
+
+	buf, err := ioutil.ReadAll(res.Body)
+	if err != nil {
+		return nil, errors.Wrap(err, "Unable to read dag get response")
+	}
+	return json.RawMessage(buf), nil
+}
+
+// Put stores the raw JSON in the IPFS dag API and
+// returns the resulting ref. The request is bound to ctx,
+// so cancelling ctx aborts the HTTP request.
+func (s *IPFSBlockStore) Put(ctx context.Context, raw json.RawMessage) (string, error) {
+	if s.URL == nil {
+		return "", errors.New("IPFS URL is nil")
+	}
+	putURL := s.URL.ResolveReference(&url.URL{Path: "api/v0/dag/put"})
+
+	body := &bytes.Buffer{}
+	writer := multipart.NewWriter(body)
+	part, err := writer.CreateFormFile("file", "file")
+	if err != nil {
+		return "", errors.Wrap(err, "Unable to CreateFormFile")
+	}
+	if _, err = io.Copy(part, bytes.NewReader(raw)); err != nil {
+		return "", errors.Wrap(err, "Unable to write form file")
+	}
+	if err = writer.Close(); err != nil {
+		return "", errors.Wrap(err, "Unable to close multipart writer")
+	}
+
+	req, err := http.NewRequest(http.MethodPost, putURL.String(), body)
+	if err != nil {
+		return "", errors.Wrap(err, "Unable to NewRequest")
+	}
+	req.Header.Set("Content-Type", writer.FormDataContentType())
+
+	res, err := s.httpClient().Do(req.WithContext(ctx))
+	if err != nil {
+		return "", errors.Wrap(err, "Unable to Post")
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode != http.StatusOK {
+		return "", errors.Wrap(readStatusError(res), "dag put failed")
+	}
+
+	parsed := struct {
+		Cid struct {
+			Address string `json:"/"`
+		}
+	}{}
+	err = json.NewDecoder(res.Body).Decode(&parsed)
+	if err != nil {
+		return "", errors.Wrap(err, "Unable to Decode dag put response")
+	}
+
+	return parsed.Cid.Address, nil
+}
+
+// readStatusError converts a non 200 response into a
+// *StatusError, using the message from the IPFS API's
+// JSON error body when there is one
+func readStatusError(res *http.Response) *StatusError {
+	buf, _ := ioutil.ReadAll(io.LimitReader(res.Body, 4096))
+	message := strings.TrimSpace(string(buf))
+
+	parsed := struct{ Message string }{}
+	if err := json.Unmarshal(buf, &parsed); err == nil && parsed.Message != "" {
+		message = parsed.Message
+	}
+
+	return &StatusError{StatusCode: res.StatusCode, Message: message}
+}
+
+func (s *IPFSBlockStore) httpClient() *http.Client {
+	if s.HTTPClient == nil {
+		return http.DefaultClient
+	}
+	return s.HTTPClient
+}
+
+// blockStoreKey identifies the IPFS API endpoint, so that
+// concurrent fetches through different IPFSBlockStore
+// instances for the same endpoint are coalesced
+func (s *IPFSBlockStore) blockStoreKey() interface{} {
+	endpoint := ""
+	if s.URL != nil {
+		endpoint = s.URL.String()
+	}
+	return struct {
+		endpoint   string
+		httpClient *http.Client
+	}{endpoint, s.HTTPClient}
+}
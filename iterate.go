@@ -0,0 +1,223 @@
+package ipldpolymorph
+
+import (
+	"context"
+	"encoding/json"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// Kind is the type of a JSON value
+type Kind int
+
+const (
+	// KindNull is the kind of null
+	KindNull Kind = iota
+
+	// KindBool is the kind of true and false
+	KindBool
+
+	// KindNumber is the kind of numbers
+	KindNumber
+
+	// KindString is the kind of strings
+	KindString
+
+	// KindObject is the kind of objects
+	KindObject
+
+	// KindArray is the kind of arrays
+	KindArray
+)
+
+// String returns the name of the Kind
+func (k Kind) String() string {
+	switch k {
+	case KindNull:
+		return "null"
+	case KindBool:
+		return "bool"
+	case KindNumber:
+		return "number"
+	case KindString:
+		return "string"
+	case KindObject:
+		return "object"
+	case KindArray:
+		return "array"
+	}
+	return "unknown"
+}
+
+// Kind returns the kind of the current value,
+// resolving the IPLD reference if necessary
+func (p *Polymorph) Kind() (Kind, error) {
+	return p.KindContext(context.Background())
+}
+
+// KindContext returns the kind of the current value,
+// resolving the IPLD reference if necessary
+func (p *Polymorph) KindContext(ctx context.Context) (Kind, error) {
+	raw, err := p.AsRawMessageContext(ctx)
+	if err != nil {
+		return KindNull, errors.Wrap(err, "AsRawMessage failed")
+	}
+	return kindOf(raw)
+}
+
+// Keys returns the sorted keys of the current object,
+// resolving the IPLD reference if necessary
+func (p *Polymorph) Keys() ([]string, error) {
+	return p.KeysContext(context.Background())
+}
+
+// KeysContext returns the sorted keys of the current object,
+// resolving the IPLD reference if necessary. Returns an error
+// wrapping ErrTypeMismatch if the value is not an object.
+func (p *Polymorph) KeysContext(ctx context.Context) ([]string, error) {
+	c, err := p.asContainer(ctx, KindObject)
+	if err != nil {
+		return nil, err
+	}
+
+	keys := make([]string, 0, len(c.object))
+	for key := range c.object {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys, nil
+}
+
+// Len returns the number of members of the current object or
+// elements of the current array, resolving the IPLD reference
+// if necessary
+func (p *Polymorph) Len() (int, error) {
+	return p.LenContext(context.Background())
+}
+
+// LenContext returns the number of members of the current object
+// or elements of the current array, resolving the IPLD reference
+// if necessary. Returns an error wrapping ErrTypeMismatch if the
+// value is neither an object nor an array.
+func (p *Polymorph) LenContext(ctx context.Context) (int, error) {
+	c, err := p.asContainer(ctx, KindObject, KindArray)
+	if err != nil {
+		return 0, err
+	}
+	if c.isArray {
+		return len(c.array), nil
+	}
+	return len(c.object), nil
+}
+
+// Range calls fn for every member of the current object or
+// element of the current array. See RangeContext for details.
+func (p *Polymorph) Range(fn func(key string, child *Polymorph) bool) error {
+	return p.RangeContext(context.Background(), fn)
+}
+
+// RangeContext calls fn for every member of the current object,
+// in sorted key order, or for every element of the current array,
+// with its decimal index as key. The IPLD reference of the current
+// value is resolved if necessary, but children are handed to fn
+// unresolved, so they are only fetched if fn uses them. Iteration
+// stops when fn returns false. Returns an error wrapping
+// ErrTypeMismatch if the value is neither an object nor an array.
+func (p *Polymorph) RangeContext(ctx context.Context, fn func(key string, child *Polymorph) bool) error {
+	c, err := p.asContainer(ctx, KindObject, KindArray)
+	if err != nil {
+		return err
+	}
+
+	if c.isArray {
+		for i, child := range c.array {
+			if !fn(strconv.Itoa(i), p.derive(child)) {
+				return nil
+			}
+		}
+		return nil
+	}
+
+	keys := make([]string, 0, len(c.object))
+	for key := range c.object {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		if !fn(key, p.derive(c.object[key])) {
+			return nil
+		}
+	}
+	return nil
+}
+
+// RangeIndex calls fn for every element of the current
+// array. See RangeIndexContext for details.
+func (p *Polymorph) RangeIndex(fn func(index int, child *Polymorph) bool) error {
+	return p.RangeIndexContext(context.Background(), fn)
+}
+
+// RangeIndexContext calls fn for every element of the current
+// array, in index order. Like RangeContext, elements are handed
+// to fn unresolved, and iteration stops when fn returns false.
+// Returns an error wrapping ErrTypeMismatch if the value is not
+// an array.
+func (p *Polymorph) RangeIndexContext(ctx context.Context, fn func(index int, child *Polymorph) bool) error {
+	c, err := p.asContainer(ctx, KindArray)
+	if err != nil {
+		return err
+	}
+
+	for i, child := range c.array {
+		if !fn(i, p.derive(child)) {
+			return nil
+		}
+	}
+	return nil
+}
+
+// asContainer returns the current value, resolving the IPLD
+// reference if necessary, decoded as a container. Returns an
+// error wrapping ErrTypeMismatch if the value is not of one of
+// the kinds.
+func (p *Polymorph) asContainer(ctx context.Context, kinds ...Kind) (*container, error) {
+	raw, err := p.AsRawMessageContext(ctx)
+	if err != nil {
+		return nil, errors.Wrap(err, "AsRawMessage failed")
+	}
+
+	kind, err := kindOf(raw)
+	if err != nil {
+		return nil, err
+	}
+	names := make([]string, len(kinds))
+	for i, expected := range kinds {
+		if kind == expected {
+			return decodeContainer(raw)
+		}
+		names[i] = expected.String()
+	}
+	return nil, errors.Wrapf(ErrTypeMismatch, "expected %v, found %v", strings.Join(names, " or "), kind)
+}
+
+// kindOf returns the kind of the raw JSON value
+func kindOf(raw json.RawMessage) (Kind, error) {
+	switch first := firstByte(raw); {
+	case first == '{':
+		return KindObject, nil
+	case first == '[':
+		return KindArray, nil
+	case first == '"':
+		return KindString, nil
+	case first == 't' || first == 'f':
+		return KindBool, nil
+	case first == 'n':
+		return KindNull, nil
+	case first == '-' || (first >= '0' && first <= '9'):
+		return KindNumber, nil
+	}
+	return KindNull, errors.New("Unable to determine the kind of invalid JSON")
+}
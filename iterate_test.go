@@ -0,0 +1,100 @@
+package ipldpolymorph_test
+
+import (
+	"strings"
+	"testing"
+
+	ipldpolymorph "github.com/computes/go-ipld-polymorph"
+	"github.com/pkg/errors"
+)
+
+func TestKeysAndLen(t *testing.T) {
+	store := ipldpolymorph.NewMemoryBlockStore()
+	refs := putLinked(t, store, `{"b":1,"a":{"/":"never-stored"}}`)
+	p := ipldpolymorph.NewWithBlockStore(store)
+	p.UnmarshalJSON([]byte(`{"/":"` + refs[0] + `"}`))
+
+	keys, err := p.Keys()
+	if err != nil {
+		t.Fatal("Could not Keys:", err.Error())
+	}
+	if strings.Join(keys, ",") != "a,b" {
+		t.Fatalf(`Expected keys == "a,b". Actual keys == "%v"`, strings.Join(keys, ","))
+	}
+
+	length, err := p.Len()
+	if err != nil {
+		t.Fatal("Could not Len:", err.Error())
+	}
+	if length != 2 {
+		t.Fatalf(`Expected length == 2. Actual length == %v`, length)
+	}
+
+	kind, err := p.Kind()
+	if err != nil {
+		t.Fatal("Could not Kind:", err.Error())
+	}
+	if kind != ipldpolymorph.KindObject {
+		t.Fatalf(`Expected kind == object. Actual kind == %v`, kind)
+	}
+}
+
+func TestRangeLeavesChildrenUnresolved(t *testing.T) {
+	p, err := ipldpolymorph.FromInterface(ipfsURL, map[string]interface{}{
+		"link":  map[string]string{"/": "never-fetched"},
+		"value": "foo",
+	})
+	if err != nil {
+		t.Fatal("Could not FromInterface:", err.Error())
+	}
+
+	visited := []string{}
+	err = p.Range(func(key string, child *ipldpolymorph.Polymorph) bool {
+		if key == "link" && !child.IsRef() {
+			t.Error(`Expected "link" to be handed over unresolved`)
+		}
+		visited = append(visited, key)
+		return true
+	})
+	if err != nil {
+		t.Fatal("Could not Range:", err.Error())
+	}
+	if strings.Join(visited, ",") != "link,value" {
+		t.Fatalf(`Expected visited == "link,value". Actual visited == "%v"`, strings.Join(visited, ","))
+	}
+}
+
+func TestRangeIndex(t *testing.T) {
+	p, err := ipldpolymorph.FromInterface(ipfsURL, []string{"a", "b", "c"})
+	if err != nil {
+		t.Fatal("Could not FromInterface:", err.Error())
+	}
+
+	visited := []string{}
+	err = p.RangeIndex(func(index int, child *ipldpolymorph.Polymorph) bool {
+		value, err := child.AsString()
+		if err != nil {
+			t.Fatal("Could not AsString:", err.Error())
+		}
+		visited = append(visited, value)
+		return index < 1
+	})
+	if err != nil {
+		t.Fatal("Could not RangeIndex:", err.Error())
+	}
+	if strings.Join(visited, ",") != "a,b" {
+		t.Fatalf(`Expected visited == "a,b". Actual visited == "%v"`, strings.Join(visited, ","))
+	}
+}
+
+func TestLenTypeMismatch(t *testing.T) {
+	p, err := ipldpolymorph.FromInterface(ipfsURL, "foo")
+	if err != nil {
+		t.Fatal("Could not FromInterface:", err.Error())
+	}
+
+	_, err = p.Len()
+	if !errors.Is(err, ipldpolymorph.ErrTypeMismatch) {
+		t.Fatalf("Expected err to be ErrTypeMismatch. Actual err == %v", err)
+	}
+}
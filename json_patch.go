@@ -0,0 +1,156 @@
+package ipldpolymorph
+
+import (
+	"context"
+	"encoding/json"
+
+	"github.com/pkg/errors"
+)
+
+// JSONPatchOperation is a single RFC 6902 JSON Patch
+// operation. Path and From are JSON Pointers.
+type JSONPatchOperation struct {
+	Op    string          `json:"op"`
+	Path  string          `json:"path"`
+	From  string          `json:"from,omitempty"`
+	Value json.RawMessage `json:"value,omitempty"`
+}
+
+// ApplyJSONPatch returns a new Polymorph with the RFC 6902 JSON
+// Patch applied. See ApplyJSONPatchContext for details.
+func (p *Polymorph) ApplyJSONPatch(patch json.RawMessage) (*Polymorph, error) {
+	return p.ApplyJSONPatchContext(context.Background(), patch)
+}
+
+// ApplyJSONPatchContext returns a new Polymorph with the RFC 6902
+// JSON Patch applied to the logical document, in which IPLD
+// references are replaced by their content. The add, remove,
+// replace, move, copy and test operations are supported. Like
+// SetContext, only the IPLD references along the paths of the
+// operations are resolved. The test operation compares logical
+// values as DiffContext does, so identical references are equal
+// without being fetched. A failed test returns an error wrapping
+// ErrTestFailed. The patch is applied as a whole or not at all,
+// p itself is never modified.
+func (p *Polymorph) ApplyJSONPatchContext(ctx context.Context, patch json.RawMessage) (*Polymorph, error) {
+	if p.raw == nil {
+		return nil, errors.Errorf("Polymorph.raw is nil")
+	}
+
+	var operations []JSONPatchOperation
+	if err := json.Unmarshal(patch, &operations); err != nil {
+		return nil, errors.Wrap(err, "Unable to Unmarshal JSON Patch")
+	}
+
+	doc := p
+	for i, operation := range operations {
+		var err error
+		doc, err = doc.applyOperation(ctx, operation)
+		if err != nil {
+			return nil, errors.Wrapf(err, `Unable to apply operation %v ("%v")`, i, operation.Op)
+		}
+	}
+	return doc, nil
+}
+
+// applyOperation returns a new Polymorph with operation applied
+func (p *Polymorph) applyOperation(ctx context.Context, operation JSONPatchOperation) (*Polymorph, error) {
+	path, err := ParsePointer(operation.Path)
+	if err != nil {
+		return nil, errors.Wrap(err, "ParsePointer failed")
+	}
+
+	switch operation.Op {
+	case "add", "replace", "test":
+		if operation.Value == nil {
+			return nil, errors.Errorf(`"%v" requires a value`, operation.Op)
+		}
+	}
+
+	switch operation.Op {
+	case "add":
+		return p.add(ctx, path, operation.Path, operation.Value, nil)
+	case "remove":
+		edited, _, _, err := p.remove(ctx, path, operation.Path)
+		return edited, err
+	case "replace":
+		if _, err = p.lookup(ctx, path, operation.Path, false); err != nil {
+			return nil, err
+		}
+		return p.set(ctx, path, operation.Path, operation.Value, nil)
+	case "move":
+		from, err := ParsePointer(operation.From)
+		if err != nil {
+			return nil, errors.Wrap(err, "ParsePointer failed")
+		}
+		return p.move(ctx, from, operation.From, path, operation.Path, true)
+	case "copy":
+		from, err := ParsePointer(operation.From)
+		if err != nil {
+			return nil, errors.Wrap(err, "ParsePointer failed")
+		}
+		raw, trie, err := p.extract(ctx, from, operation.From)
+		if err != nil {
+			return nil, err
+		}
+		return p.add(ctx, path, operation.Path, raw, trie)
+	case "test":
+		return p, p.test(ctx, path, operation.Path, operation.Value)
+	}
+	return nil, errors.Errorf(`unknown JSON Patch operation "%v"`, operation.Op)
+}
+
+// test returns an error wrapping ErrTestFailed if the
+// logical value at path is different from value
+func (p *Polymorph) test(ctx context.Context, path Path, display string, value json.RawMessage) error {
+	actual, err := p.lookup(ctx, path, display, false)
+	if err != nil {
+		return err
+	}
+
+	changes, err := DiffContext(ctx, p.derive(actual), p.derive(value))
+	if err != nil {
+		return errors.Wrap(err, "Diff failed")
+	}
+	if len(changes) > 0 {
+		return errors.Wrapf(ErrTestFailed, `value at "%v" is different`, display)
+	}
+	return nil
+}
+
+// CreateJSONPatch returns the RFC 6902 JSON Patch that turns
+// a into b. See CreateJSONPatchContext for details.
+func CreateJSONPatch(a, b *Polymorph) (json.RawMessage, error) {
+	return CreateJSONPatchContext(context.Background(), a, b)
+}
+
+// CreateJSONPatchContext returns the RFC 6902 JSON Patch that
+// turns a into b, made of the add, remove and replace operations
+// for the changes found by DiffContext. Values are taken from b
+// as they are stored in it, so they may be IPLD references.
+func CreateJSONPatchContext(ctx context.Context, a, b *Polymorph) (json.RawMessage, error) {
+	changes, err := DiffContext(ctx, a, b)
+	if err != nil {
+		return nil, errors.Wrap(err, "Diff failed")
+	}
+
+	operations := make([]JSONPatchOperation, 0, len(changes))
+	for _, change := range changes {
+		operation := JSONPatchOperation{Path: change.Path.String(), Value: change.To}
+		switch change.Type {
+		case Added:
+			operation.Op = "add"
+		case Removed:
+			operation.Op = "remove"
+		case Changed:
+			operation.Op = "replace"
+		}
+		operations = append(operations, operation)
+	}
+
+	buf, err := json.Marshal(operations)
+	if err != nil {
+		return nil, errors.Wrap(err, "Unable to json.Marshal")
+	}
+	return json.RawMessage(buf), nil
+}
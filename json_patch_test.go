@@ -0,0 +1,89 @@
+package ipldpolymorph_test
+
+import (
+	"encoding/json"
+	"testing"
+
+	ipldpolymorph "github.com/computes/go-ipld-polymorph"
+	"github.com/pkg/errors"
+)
+
+func TestApplyJSONPatch(t *testing.T) {
+	store := ipldpolymorph.NewMemoryBlockStore()
+	refs := putLinked(t, store,
+		`{"name":"build"}`,
+		`{"steps":[{"/":"$0"}],"meta":{"owner":"me","tmp":null}}`,
+	)
+	p := ipldpolymorph.NewWithBlockStore(store)
+	p.UnmarshalJSON([]byte(`{"/":"` + refs[1] + `"}`))
+
+	patched, err := p.ApplyJSONPatch(json.RawMessage(`[
+		{"op":"test","path":"/steps/0","value":{"/":"` + refs[0] + `"}},
+		{"op":"test","path":"/steps/0/name","value":"build"},
+		{"op":"add","path":"/steps/0","value":{"name":"lint"}},
+		{"op":"replace","path":"/steps/1/name","value":"compile"},
+		{"op":"copy","from":"/meta/owner","path":"/steps/1/owner"},
+		{"op":"move","from":"/meta/owner","path":"/owner"},
+		{"op":"remove","path":"/meta/tmp"}
+	]`))
+	if err != nil {
+		t.Fatal("Could not ApplyJSONPatch:", err.Error())
+	}
+
+	expected := `{"meta":{},"owner":"me","steps":[{"name":"lint"},{"name":"compile","owner":"me"}]}`
+	buf, err := patched.MarshalJSON()
+	if err != nil {
+		t.Fatal("Could not MarshalJSON:", err.Error())
+	}
+	if string(buf) != expected {
+		t.Fatalf(`Expected json == %v. Actual json == %v`, expected, string(buf))
+	}
+
+	name, err := p.GetString("steps/0/name")
+	if err != nil {
+		t.Fatal(`Could not GetString for path "steps/0/name":`, err.Error())
+	}
+	if name != "build" {
+		t.Fatalf(`Expected original name == "build". Actual name == "%v"`, name)
+	}
+}
+
+func TestApplyJSONPatchTestFailed(t *testing.T) {
+	p, err := ipldpolymorph.FromInterface(ipfsURL, map[string]interface{}{
+		"foo": "bar",
+	})
+	if err != nil {
+		t.Fatal("Could not FromInterface:", err.Error())
+	}
+
+	_, err = p.ApplyJSONPatch(json.RawMessage(`[{"op":"test","path":"/foo","value":"baz"}]`))
+	if !errors.Is(err, ipldpolymorph.ErrTestFailed) {
+		t.Fatalf("Expected err to be ErrTestFailed. Actual err == %v", err)
+	}
+}
+
+func TestCreateJSONPatch(t *testing.T) {
+	store := ipldpolymorph.NewMemoryBlockStore()
+	a := ipldpolymorph.NewWithBlockStore(store)
+	a.UnmarshalJSON([]byte(`{"shared":{"/":"never-stored"},"list":[1,2,3],"foo":"bar","gone":true}`))
+	b := ipldpolymorph.NewWithBlockStore(store)
+	b.UnmarshalJSON([]byte(`{"shared":{"/":"never-stored"},"list":[1],"foo":"baz","new":null}`))
+
+	patch, err := ipldpolymorph.CreateJSONPatch(a, b)
+	if err != nil {
+		t.Fatal("Could not CreateJSONPatch:", err.Error())
+	}
+
+	patched, err := a.ApplyJSONPatch(patch)
+	if err != nil {
+		t.Fatal("Could not ApplyJSONPatch:", err.Error())
+	}
+
+	changes, err := ipldpolymorph.Diff(patched, b)
+	if err != nil {
+		t.Fatal("Could not Diff:", err.Error())
+	}
+	if len(changes) != 0 {
+		t.Fatalf(`Expected no changes after applying %v. Actual changes == %v`, string(patch), changes)
+	}
+}
@@ -0,0 +1,185 @@
+package ipldpolymorph
+
+import (
+	"context"
+	"encoding/json"
+	"reflect"
+	"sync"
+
+	"github.com/pkg/errors"
+)
+
+// Link holds a T that is stored either inline or behind an IPLD
+// reference, and is meant to be used as the type of struct
+// fields. The value is only resolved and decoded when Load is
+// called, and the result is kept for subsequent calls. A Link
+// decoded by a Polymorph, e.g. through As or ToInterface,
+// resolves references like that Polymorph does, one decoded by
+// encoding/json alone uses DefaultClient. NewLink creates a Link
+// resolving them like an existing Polymorph does. Copies of a
+// Link share its loaded value.
+type Link[T any] struct {
+	poly  *Polymorph
+	cache *linkCache[T]
+}
+
+// linkCache holds the value a Link loaded
+type linkCache[T any] struct {
+	mutex  sync.Mutex
+	loaded bool
+	value  T
+
+	// unbound is set when the Link was decoded by
+	// UnmarshalJSON and is not yet bound to the
+	// Polymorph it was decoded by
+	unbound bool
+}
+
+// NewLink returns a Link to the value of p, which
+// resolves IPLD references the same way p does
+func NewLink[T any](p *Polymorph) Link[T] {
+	return Link[T]{poly: p, cache: &linkCache[T]{}}
+}
+
+// IsRef returns true if the value is stored
+// behind an IPLD reference
+func (l Link[T]) IsRef() bool {
+	return l.poly != nil && l.poly.IsRef()
+}
+
+// Ref returns the ref the value is stored behind,
+// or an empty string if it is stored inline
+func (l Link[T]) Ref() string {
+	if l.poly == nil {
+		return ""
+	}
+	return l.poly.AsRef()
+}
+
+// Polymorph returns the Polymorph holding the value,
+// which is nil if nothing was unmarshaled into the Link
+func (l Link[T]) Polymorph() *Polymorph {
+	return l.poly
+}
+
+// Load returns the value, resolving the IPLD reference if
+// necessary, and decoding it like AsContext does. The value
+// is only resolved and decoded once, failures are not kept,
+// so a failed Load can be retried.
+func (l Link[T]) Load(ctx context.Context) (T, error) {
+	if l.poly == nil {
+		var zero T
+		return zero, errors.New("Link is empty")
+	}
+
+	l.cache.mutex.Lock()
+	defer l.cache.mutex.Unlock()
+	if l.cache.loaded {
+		return l.cache.value, nil
+	}
+
+	value, err := AsContext[T](ctx, l.poly)
+	if err != nil {
+		return value, err
+	}
+	l.cache.value = value
+	l.cache.loaded = true
+	return value, nil
+}
+
+// MarshalJSON returns the JSON the Link was unmarshaled
+// from, so references stay references. An empty Link is
+// marshaled as null.
+func (l Link[T]) MarshalJSON() ([]byte, error) {
+	if l.poly == nil {
+		return []byte("null"), nil
+	}
+	return l.poly.MarshalJSON()
+}
+
+// UnmarshalJSON defers parsing json until Load is called
+func (l *Link[T]) UnmarshalJSON(b []byte) error {
+	raw := make(json.RawMessage, len(b))
+	copy(raw, b)
+
+	l.poly = DefaultClient.New()
+	l.cache = &linkCache[T]{unbound: true}
+	return l.poly.UnmarshalJSON(raw)
+}
+
+// bind makes a Link decoded by UnmarshalJSON resolve IPLD
+// references like p does. Other Links are left as they are.
+func (l Link[T]) bind(p *Polymorph) {
+	if l.cache == nil {
+		return
+	}
+
+	l.cache.mutex.Lock()
+	defer l.cache.mutex.Unlock()
+	if !l.cache.unbound {
+		return
+	}
+	*l.poly = *p.derive(l.poly.raw)
+	l.cache.unbound = false
+}
+
+// linkBinder is implemented by every Link
+type linkBinder interface {
+	bind(p *Polymorph)
+}
+
+// bindLinks binds every Link reachable from value to p,
+// so Links decoded by p resolve IPLD references like p does
+func bindLinks(value reflect.Value, p *Polymorph) {
+	bindLinksVisited(value, p, map[uintptr]bool{})
+}
+
+func bindLinksVisited(value reflect.Value, p *Polymorph, visited map[uintptr]bool) {
+	if !value.IsValid() {
+		return
+	}
+	if value.CanInterface() {
+		if binder, ok := value.Interface().(linkBinder); ok {
+			binder.bind(p)
+			return
+		}
+	}
+
+	switch value.Kind() {
+	case reflect.Ptr:
+		if value.IsNil() || visited[value.Pointer()] {
+			return
+		}
+		visited[value.Pointer()] = true
+		bindLinksVisited(value.Elem(), p, visited)
+	case reflect.Interface:
+		if !value.IsNil() {
+			bindLinksVisited(value.Elem(), p, visited)
+		}
+	case reflect.Struct:
+		for i := 0; i < value.NumField(); i++ {
+			bindLinksVisited(value.Field(i), p, visited)
+		}
+	case reflect.Slice, reflect.Array:
+		if isScalarKind(value.Type().Elem().Kind()) {
+			return
+		}
+		for i := 0; i < value.Len(); i++ {
+			bindLinksVisited(value.Index(i), p, visited)
+		}
+	case reflect.Map:
+		if isScalarKind(value.Type().Elem().Kind()) {
+			return
+		}
+		iter := value.MapRange()
+		for iter.Next() {
+			bindLinksVisited(iter.Value(), p, visited)
+		}
+	}
+}
+
+// isScalarKind returns true for kinds
+// whose values can't hold a Link
+func isScalarKind(kind reflect.Kind) bool {
+	return kind >= reflect.Bool && kind <= reflect.Complex128 || kind == reflect.String
+}
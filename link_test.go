@@ -0,0 +1,134 @@
+package ipldpolymorph_test
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"testing"
+
+	ipldpolymorph "github.com/computes/go-ipld-polymorph"
+)
+
+type manifest struct {
+	Name string                       `json:"name"`
+	Job  ipldpolymorph.Link[job]      `json:"job"`
+	Tags ipldpolymorph.Link[[]string] `json:"tags"`
+}
+
+func TestLink(t *testing.T) {
+	beforeEach()
+	httpResponses[http.MethodGet]["/api/v0/dag/get?arg=job"] = `{"name":"build","steps":["compile"]}`
+	ipldpolymorph.DefaultClient.URL = ipfsURL
+	defer func() { ipldpolymorph.DefaultClient.URL = nil }()
+
+	original := `{"name":"nightly","job":{"/":"job"},"tags":["a","b"]}`
+	var m manifest
+	if err := json.Unmarshal([]byte(original), &m); err != nil {
+		t.Fatal("Could not Unmarshal:", err.Error())
+	}
+
+	if !m.Job.IsRef() || m.Job.Ref() != "job" {
+		t.Fatalf(`Expected job to be a ref to "job". Actual ref == "%v"`, m.Job.Ref())
+	}
+	if m.Tags.IsRef() {
+		t.Fatal("Expected tags to be inline")
+	}
+
+	value, err := m.Job.Load(context.Background())
+	if err != nil {
+		t.Fatal("Could not Load job:", err.Error())
+	}
+	if value.Name != "build" {
+		t.Fatalf(`Expected job.Name == "build". Actual job.Name == "%v"`, value.Name)
+	}
+
+	delete(httpResponses[http.MethodGet], "/api/v0/dag/get?arg=job")
+	value, err = m.Job.Load(context.Background())
+	if err != nil {
+		t.Fatal("Could not Load cached job:", err.Error())
+	}
+	if value.Name != "build" {
+		t.Fatalf(`Expected cached job.Name == "build". Actual job.Name == "%v"`, value.Name)
+	}
+
+	tags, err := m.Tags.Load(context.Background())
+	if err != nil {
+		t.Fatal("Could not Load tags:", err.Error())
+	}
+	if len(tags) != 2 {
+		t.Fatalf(`Expected len(tags) == 2. Actual len(tags) == %v`, len(tags))
+	}
+
+	buf, err := json.Marshal(m)
+	if err != nil {
+		t.Fatal("Could not Marshal:", err.Error())
+	}
+	if string(buf) != original {
+		t.Fatalf(`Expected json == %v. Actual json == %s`, original, buf)
+	}
+}
+
+func TestNewLink(t *testing.T) {
+	store := ipldpolymorph.NewMemoryBlockStore()
+	refs := putLinked(t, store, `"red"`)
+	p := ipldpolymorph.NewWithBlockStore(store)
+	p.UnmarshalJSON([]byte(`{"/":"` + refs[0] + `"}`))
+
+	value, err := ipldpolymorph.NewLink[string](p).Load(context.Background())
+	if err != nil {
+		t.Fatal("Could not Load:", err.Error())
+	}
+	if value != "red" {
+		t.Fatalf(`Expected value == "red". Actual value == "%v"`, value)
+	}
+}
+
+type release struct {
+	Manifest ipldpolymorph.Link[map[string]interface{}] `json:"manifest"`
+	Jobs     []ipldpolymorph.Link[job]                  `json:"jobs"`
+	Extra    map[string]ipldpolymorph.Link[release]     `json:"extra"`
+}
+
+func TestLinkAs(t *testing.T) {
+	store := ipldpolymorph.NewMemoryBlockStore()
+	refs := putLinked(t, store,
+		`{"name":"nightly"}`,
+		`{"name":"build","steps":["compile"]}`,
+		`{"jobs":[{"/":"$1"}]}`,
+	)
+	p := ipldpolymorph.NewWithBlockStore(store)
+	p.UnmarshalJSON([]byte(`{"manifest":{"/":"` + refs[0] + `"},"jobs":[{"/":"` + refs[1] + `"}],"extra":{"next":{"/":"` + refs[2] + `"}}}`))
+
+	r, err := ipldpolymorph.As[release](p)
+	if err != nil {
+		t.Fatal("Could not As:", err.Error())
+	}
+
+	manifest, err := r.Manifest.Load(context.Background())
+	if err != nil {
+		t.Fatal("Could not Load manifest:", err.Error())
+	}
+	if manifest["name"] != "nightly" {
+		t.Fatalf(`Expected manifest.name == "nightly". Actual manifest.name == "%v"`, manifest["name"])
+	}
+
+	value, err := r.Jobs[0].Load(context.Background())
+	if err != nil {
+		t.Fatal("Could not Load job:", err.Error())
+	}
+	if value.Name != "build" {
+		t.Fatalf(`Expected job.Name == "build". Actual job.Name == "%v"`, value.Name)
+	}
+
+	next, err := r.Extra["next"].Load(context.Background())
+	if err != nil {
+		t.Fatal("Could not Load next:", err.Error())
+	}
+	value, err = next.Jobs[0].Load(context.Background())
+	if err != nil {
+		t.Fatal("Could not Load nested job:", err.Error())
+	}
+	if value.Name != "build" {
+		t.Fatalf(`Expected nested job.Name == "build". Actual job.Name == "%v"`, value.Name)
+	}
+}
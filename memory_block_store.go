@@ -0,0 +1,54 @@
+package ipldpolymorph
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"sync"
+
+	"github.com/pkg/errors"
+)
+
+// MemoryBlockStore implements BlockStore by keeping
+// every block in memory. Refs are the hex encoded
+// sha256 of the block, so identical blocks share a ref.
+// It is useful for tests and short lived documents.
+type MemoryBlockStore struct {
+	blocks *sync.Map
+}
+
+// NewMemoryBlockStore returns an empty instance of
+// MemoryBlockStore, which can be used as BlockStore
+func NewMemoryBlockStore() BlockStore {
+	return &MemoryBlockStore{
+		blocks: &sync.Map{},
+	}
+}
+
+// Get returns the raw JSON stored at ref, or
+// ErrBlockNotFound if no block was ever Put at ref
+func (s *MemoryBlockStore) Get(ctx context.Context, ref string) (json.RawMessage, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	val, ok := s.blocks.Load(ref)
+	if !ok {
+		return nil, errors.Wrapf(ErrBlockNotFound, `no block at "%v"`, ref)
+	}
+	return val.(json.RawMessage), nil
+}
+
+// Put stores the raw JSON and returns its ref
+func (s *MemoryBlockStore) Put(ctx context.Context, raw json.RawMessage) (string, error) {
+	if err := ctx.Err(); err != nil {
+		return "", err
+	}
+	sum := sha256.Sum256(raw)
+	ref := hex.EncodeToString(sum[:])
+
+	value := make(json.RawMessage, len(raw))
+	copy(value, raw)
+	s.blocks.Store(ref, value)
+	return ref, nil
+}
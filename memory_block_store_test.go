@@ -0,0 +1,56 @@
+package ipldpolymorph_test
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	ipldpolymorph "github.com/computes/go-ipld-polymorph"
+)
+
+func TestMemoryBlockStore(t *testing.T) {
+	store := ipldpolymorph.NewMemoryBlockStore()
+
+	ref, err := store.Put(context.Background(), json.RawMessage(`"bar"`))
+	if err != nil {
+		t.Fatal("Could not Put:", err.Error())
+	}
+
+	raw, err := store.Get(context.Background(), ref)
+	if err != nil {
+		t.Fatal("Could not Get:", err.Error())
+	}
+
+	if string(raw) != `"bar"` {
+		t.Fatalf(`Expected raw == "bar". Actual raw == %s`, raw)
+	}
+}
+
+func TestMemoryBlockStoreSameRef(t *testing.T) {
+	store := ipldpolymorph.NewMemoryBlockStore()
+
+	ref1, err := store.Put(context.Background(), json.RawMessage(`{"foo":"bar"}`))
+	if err != nil {
+		t.Fatal("Could not Put:", err.Error())
+	}
+	ref2, err := store.Put(context.Background(), json.RawMessage(`{"foo":"bar"}`))
+	if err != nil {
+		t.Fatal("Could not Put:", err.Error())
+	}
+
+	if ref1 != ref2 {
+		t.Fatalf(`Expected ref1 == ref2. Actual ref1 == "%v", ref2 == "%v"`, ref1, ref2)
+	}
+}
+
+func TestMemoryBlockStoreNotFound(t *testing.T) {
+	store := ipldpolymorph.NewMemoryBlockStore()
+
+	raw, err := store.Get(context.Background(), "foo")
+	if err == nil {
+		t.Fatal("Expected Get to return an error, received nil")
+	}
+	if raw != nil {
+		t.Fatal("Expected Get to return a nil response, received", raw)
+	}
+}
@@ -0,0 +1,95 @@
+package ipldpolymorph
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+
+	"github.com/pkg/errors"
+)
+
+// ApplyMergePatch returns a new Polymorph with the RFC 7386 JSON
+// Merge Patch applied. See ApplyMergePatchContext for details.
+func (p *Polymorph) ApplyMergePatch(patch json.RawMessage) (*Polymorph, error) {
+	return p.ApplyMergePatchContext(context.Background(), patch)
+}
+
+// ApplyMergePatchContext returns a new Polymorph with the RFC 7386
+// JSON Merge Patch applied to the logical document, in which IPLD
+// references are replaced by their content. Only the references
+// to objects that the patch merges into are resolved, every other
+// reference is left as it is. A patch value that is itself an IPLD
+// reference replaces the target value, instead of being merged into
+// it. Like SetContext, p itself is not modified, and the result
+// can be written back with Commit.
+func (p *Polymorph) ApplyMergePatchContext(ctx context.Context, patch json.RawMessage) (*Polymorph, error) {
+	if p.raw == nil {
+		return nil, errors.Errorf("Polymorph.raw is nil")
+	}
+	if !json.Valid(patch) {
+		return nil, errors.New("Unable to ApplyMergePatch: invalid JSON")
+	}
+
+	raw, trie, err := p.mergePatch(ctx, p.raw, p.links, patch, nil, Path{})
+	if err != nil {
+		return nil, err
+	}
+	return p.edited(raw, trie), nil
+}
+
+// mergePatch applies patch to target, which is the value at path.
+// target is nil if there is no value at path. trie records the
+// references inlined inside of target, and chain holds the refs
+// followed to get to it.
+func (p *Polymorph) mergePatch(ctx context.Context, target json.RawMessage, trie *linkTrie, patch json.RawMessage, chain []string, path Path) (json.RawMessage, *linkTrie, error) {
+	if firstByte(patch) != '{' || IsRef(patch) {
+		return patch, nil, nil
+	}
+
+	var err error
+	trie = trie.clone()
+	if IsRef(target) {
+		target, chain, err = p.resolve(ctx, target, chain)
+		if err != nil {
+			return nil, nil, errors.Wrapf(err, `Unable to resolve "%v"`, path)
+		}
+		trie.inlined = true
+	}
+
+	c := &container{object: make(map[string]json.RawMessage)}
+	if firstByte(target) == '{' {
+		c, err = decodeContainer(target)
+		if err != nil {
+			return nil, nil, errors.Wrapf(err, `Unable to decode "%v"`, path)
+		}
+	} else {
+		// the target is replaced by a new object
+		trie = &linkTrie{}
+	}
+
+	members := make(map[string]json.RawMessage)
+	if err = json.Unmarshal(patch, &members); err != nil {
+		return nil, nil, errors.Wrap(err, "Unable to Unmarshal merge patch")
+	}
+
+	for key, value := range members {
+		if bytes.Equal(bytes.TrimSpace(value), []byte("null")) {
+			delete(c.object, key)
+			trie.setChild(key, nil)
+			continue
+		}
+
+		child, childTrie, err := p.mergePatch(ctx, c.object[key], trie.child(key), value, chain, path.Append(key))
+		if err != nil {
+			return nil, nil, err
+		}
+		c.object[key] = child
+		trie.setChild(key, childTrie)
+	}
+
+	raw, err := c.encode()
+	if err != nil {
+		return nil, nil, err
+	}
+	return raw, trie.prune(), nil
+}
@@ -0,0 +1,81 @@
+package ipldpolymorph_test
+
+import (
+	"encoding/json"
+	"testing"
+
+	ipldpolymorph "github.com/computes/go-ipld-polymorph"
+)
+
+func TestApplyMergePatch(t *testing.T) {
+	store := ipldpolymorph.NewMemoryBlockStore()
+	refs := putLinked(t, store,
+		`{"b":1,"c":2}`,
+		`{"a":{"/":"$0"},"untouched":{"/":"never-stored"},"gone":1}`,
+	)
+	p := ipldpolymorph.NewWithBlockStore(store)
+	p.UnmarshalJSON([]byte(`{"/":"` + refs[1] + `"}`))
+
+	patched, err := p.ApplyMergePatch(json.RawMessage(`{"a":{"b":null,"d":{"e":null,"f":3}},"gone":null}`))
+	if err != nil {
+		t.Fatal("Could not ApplyMergePatch:", err.Error())
+	}
+
+	a, err := patched.GetRawMessage("a")
+	if err != nil {
+		t.Fatal(`Could not GetRawMessage for path "a":`, err.Error())
+	}
+	if string(a) != `{"c":2,"d":{"f":3}}` {
+		t.Fatalf(`Expected a == {"c":2,"d":{"f":3}}. Actual a == %v`, string(a))
+	}
+
+	_, err = patched.GetRawMessage("gone")
+	if err == nil {
+		t.Fatal(`Expected "gone" to be removed`)
+	}
+
+	untouched, err := patched.GetUnresolvedPolymorph("untouched")
+	if err != nil {
+		t.Fatal(`Could not GetUnresolvedPolymorph for path "untouched":`, err.Error())
+	}
+	if untouched.AsRef() != "never-stored" {
+		t.Fatalf(`Expected untouched ref == "never-stored". Actual untouched ref == "%v"`, untouched.AsRef())
+	}
+
+	root, _, err := patched.Commit()
+	if err != nil {
+		t.Fatal("Could not Commit:", err.Error())
+	}
+	committed := ipldpolymorph.NewWithBlockStore(store)
+	committed.UnmarshalJSON([]byte(`{"/":"` + root + `"}`))
+
+	linked, err := committed.GetUnresolvedPolymorph("a")
+	if err != nil {
+		t.Fatal(`Could not GetUnresolvedPolymorph for path "a":`, err.Error())
+	}
+	if !linked.IsRef() {
+		t.Fatal(`Expected "a" to be committed as a ref`)
+	}
+}
+
+func TestApplyMergePatchReplacesNonObject(t *testing.T) {
+	p, err := ipldpolymorph.FromInterface(ipfsURL, map[string]interface{}{
+		"a": []int{1, 2},
+	})
+	if err != nil {
+		t.Fatal("Could not FromInterface:", err.Error())
+	}
+
+	patched, err := p.ApplyMergePatch(json.RawMessage(`{"a":{"b":"c"}}`))
+	if err != nil {
+		t.Fatal("Could not ApplyMergePatch:", err.Error())
+	}
+
+	buf, err := patched.MarshalJSON()
+	if err != nil {
+		t.Fatal("Could not MarshalJSON:", err.Error())
+	}
+	if string(buf) != `{"a":{"b":"c"}}` {
+		t.Fatalf(`Expected json == {"a":{"b":"c"}}. Actual json == %v`, string(buf))
+	}
+}
@@ -0,0 +1,212 @@
+package ipldpolymorph
+
+import (
+	"context"
+	"encoding/json"
+	"math/big"
+	"strconv"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// AsNumber returns the current value as a json.Number,
+// resolving the IPLD reference if necessary
+func (p *Polymorph) AsNumber() (json.Number, error) {
+	return p.AsNumberContext(context.Background())
+}
+
+// AsNumberContext returns the current value as a json.Number,
+// resolving the IPLD reference if necessary. The number is
+// returned exactly as it is written, so no precision is lost.
+// Returns an error wrapping ErrTypeMismatch if the value is not
+// a number, which includes strings holding a number.
+func (p *Polymorph) AsNumberContext(ctx context.Context) (json.Number, error) {
+	raw, err := p.AsRawMessageContext(ctx)
+	if err != nil {
+		return "", errors.Wrap(err, "AsRawMessage failed")
+	}
+
+	kind, err := kindOf(raw)
+	if err != nil {
+		return "", err
+	}
+	if kind != KindNumber {
+		return "", errors.Wrapf(ErrTypeMismatch, "expected number, found %v", kind)
+	}
+	if !json.Valid(raw) {
+		return "", errors.New("Unmarshal failed: invalid JSON")
+	}
+	return json.Number(strings.TrimSpace(string(raw))), nil
+}
+
+// AsInt64 returns the current value as an int64,
+// resolving the IPLD reference if necessary
+func (p *Polymorph) AsInt64() (int64, error) {
+	return p.AsInt64Context(context.Background())
+}
+
+// AsInt64Context returns the current value as an int64, resolving
+// the IPLD reference if necessary. Returns an error wrapping
+// ErrTypeMismatch if the value is not an integer, and one wrapping
+// ErrOverflow if it does not fit into an int64.
+func (p *Polymorph) AsInt64Context(ctx context.Context) (int64, error) {
+	n, err := p.AsNumberContext(ctx)
+	if err != nil {
+		return 0, err
+	}
+
+	i, err := strconv.ParseInt(string(n), 10, 64)
+	if err == nil {
+		return i, nil
+	}
+
+	integer, err := parseInteger(n)
+	if err != nil {
+		return 0, err
+	}
+	if !integer.IsInt64() {
+		return 0, errors.Wrapf(ErrOverflow, "%v does not fit into an int64", n)
+	}
+	return integer.Int64(), nil
+}
+
+// AsUint64 returns the current value as a uint64,
+// resolving the IPLD reference if necessary
+func (p *Polymorph) AsUint64() (uint64, error) {
+	return p.AsUint64Context(context.Background())
+}
+
+// AsUint64Context returns the current value as a uint64, resolving
+// the IPLD reference if necessary. Returns an error wrapping
+// ErrTypeMismatch if the value is not an integer, and one wrapping
+// ErrOverflow if it is negative or does not fit into a uint64.
+func (p *Polymorph) AsUint64Context(ctx context.Context) (uint64, error) {
+	n, err := p.AsNumberContext(ctx)
+	if err != nil {
+		return 0, err
+	}
+
+	u, err := strconv.ParseUint(string(n), 10, 64)
+	if err == nil {
+		return u, nil
+	}
+
+	integer, err := parseInteger(n)
+	if err != nil {
+		return 0, err
+	}
+	if !integer.IsUint64() {
+		return 0, errors.Wrapf(ErrOverflow, "%v does not fit into a uint64", n)
+	}
+	return integer.Uint64(), nil
+}
+
+// AsFloat64 returns the current value as a float64,
+// resolving the IPLD reference if necessary
+func (p *Polymorph) AsFloat64() (float64, error) {
+	return p.AsFloat64Context(context.Background())
+}
+
+// AsFloat64Context returns the current value as a float64,
+// resolving the IPLD reference if necessary. Returns an error
+// wrapping ErrTypeMismatch if the value is not a number, and
+// one wrapping ErrOverflow if it is too large for a float64.
+func (p *Polymorph) AsFloat64Context(ctx context.Context) (float64, error) {
+	n, err := p.AsNumberContext(ctx)
+	if err != nil {
+		return 0, err
+	}
+
+	f, err := strconv.ParseFloat(string(n), 64)
+	if numErr, ok := err.(*strconv.NumError); ok && numErr.Err == strconv.ErrRange {
+		return 0, errors.Wrapf(ErrOverflow, "%v does not fit into a float64", n)
+	}
+	if err != nil {
+		return 0, errors.Wrap(err, "ParseFloat failed")
+	}
+	return f, nil
+}
+
+// parseInteger parses the number exactly, which accepts
+// integers written with a fraction or an exponent, e.g. 1e3.
+// Returns an error wrapping ErrTypeMismatch if the number
+// is not an integer.
+func parseInteger(n json.Number) (*big.Int, error) {
+	r, ok := new(big.Rat).SetString(string(n))
+	if !ok {
+		return nil, errors.Errorf("Unable to parse number %v", n)
+	}
+	if !r.IsInt() {
+		return nil, errors.Wrapf(ErrTypeMismatch, "%v is not an integer", n)
+	}
+	return r.Num(), nil
+}
+
+// GetNumber returns the json.Number value at path, resolving
+// IPLD references if necessary to get there.
+func (p *Polymorph) GetNumber(path string) (json.Number, error) {
+	return p.GetNumberContext(context.Background(), path)
+}
+
+// GetNumberContext returns the json.Number value at path, resolving
+// IPLD references if necessary to get there.
+func (p *Polymorph) GetNumberContext(ctx context.Context, path string) (json.Number, error) {
+	poly, err := p.GetPolymorphContext(ctx, path)
+	if err != nil {
+		return "", errors.Wrap(err, "GetPolymorph failed")
+	}
+
+	return poly.AsNumberContext(ctx)
+}
+
+// GetInt64 returns the int64 value at path, resolving
+// IPLD references if necessary to get there.
+func (p *Polymorph) GetInt64(path string) (int64, error) {
+	return p.GetInt64Context(context.Background(), path)
+}
+
+// GetInt64Context returns the int64 value at path, resolving
+// IPLD references if necessary to get there.
+func (p *Polymorph) GetInt64Context(ctx context.Context, path string) (int64, error) {
+	poly, err := p.GetPolymorphContext(ctx, path)
+	if err != nil {
+		return 0, errors.Wrap(err, "GetPolymorph failed")
+	}
+
+	return poly.AsInt64Context(ctx)
+}
+
+// GetUint64 returns the uint64 value at path, resolving
+// IPLD references if necessary to get there.
+func (p *Polymorph) GetUint64(path string) (uint64, error) {
+	return p.GetUint64Context(context.Background(), path)
+}
+
+// GetUint64Context returns the uint64 value at path, resolving
+// IPLD references if necessary to get there.
+func (p *Polymorph) GetUint64Context(ctx context.Context, path string) (uint64, error) {
+	poly, err := p.GetPolymorphContext(ctx, path)
+	if err != nil {
+		return 0, errors.Wrap(err, "GetPolymorph failed")
+	}
+
+	return poly.AsUint64Context(ctx)
+}
+
+// GetFloat64 returns the float64 value at path, resolving
+// IPLD references if necessary to get there.
+func (p *Polymorph) GetFloat64(path string) (float64, error) {
+	return p.GetFloat64Context(context.Background(), path)
+}
+
+// GetFloat64Context returns the float64 value at path, resolving
+// IPLD references if necessary to get there.
+func (p *Polymorph) GetFloat64Context(ctx context.Context, path string) (float64, error) {
+	poly, err := p.GetPolymorphContext(ctx, path)
+	if err != nil {
+		return 0, errors.Wrap(err, "GetPolymorph failed")
+	}
+
+	return poly.AsFloat64Context(ctx)
+}
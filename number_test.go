@@ -0,0 +1,100 @@
+package ipldpolymorph_test
+
+import (
+	"net/http"
+	"testing"
+
+	ipldpolymorph "github.com/computes/go-ipld-polymorph"
+	"github.com/pkg/errors"
+)
+
+func TestGetInt64(t *testing.T) {
+	beforeEach()
+	httpResponses[http.MethodGet]["/api/v0/dag/get?arg=foo"] = `{"big":9007199254740993,"exp":1e3}`
+	p := ipldpolymorph.FromRef(ipfsURL, "foo")
+
+	big, err := p.GetInt64("big")
+	if err != nil {
+		t.Fatal(`Could not GetInt64 for path "big":`, err.Error())
+	}
+	if big != 9007199254740993 {
+		t.Fatalf(`Expected big == 9007199254740993. Actual big == %v`, big)
+	}
+
+	exp, err := p.GetInt64("exp")
+	if err != nil {
+		t.Fatal(`Could not GetInt64 for path "exp":`, err.Error())
+	}
+	if exp != 1000 {
+		t.Fatalf(`Expected exp == 1000. Actual exp == %v`, exp)
+	}
+}
+
+func TestAsInt64Errors(t *testing.T) {
+	cases := map[string]error{
+		`1.5`:                  ipldpolymorph.ErrTypeMismatch,
+		`"1"`:                  ipldpolymorph.ErrTypeMismatch,
+		`9223372036854775808`:  ipldpolymorph.ErrOverflow,
+		`-9223372036854775809`: ipldpolymorph.ErrOverflow,
+	}
+	for raw, expected := range cases {
+		p := ipldpolymorph.New(ipfsURL)
+		p.UnmarshalJSON([]byte(raw))
+
+		_, err := p.AsInt64()
+		if !errors.Is(err, expected) {
+			t.Errorf(`Expected AsInt64 of %v to fail with "%v". Actual err == %v`, raw, expected, err)
+		}
+	}
+}
+
+func TestAsUint64(t *testing.T) {
+	p := ipldpolymorph.New(ipfsURL)
+	p.UnmarshalJSON([]byte(`18446744073709551615`))
+
+	u, err := p.AsUint64()
+	if err != nil {
+		t.Fatal("Could not AsUint64:", err.Error())
+	}
+	if u != 18446744073709551615 {
+		t.Fatalf(`Expected u == 18446744073709551615. Actual u == %v`, u)
+	}
+
+	p.UnmarshalJSON([]byte(`-1`))
+	_, err = p.AsUint64()
+	if !errors.Is(err, ipldpolymorph.ErrOverflow) {
+		t.Fatalf("Expected err to be ErrOverflow. Actual err == %v", err)
+	}
+}
+
+func TestAsFloat64(t *testing.T) {
+	p := ipldpolymorph.New(ipfsURL)
+	p.UnmarshalJSON([]byte(`1.5`))
+
+	f, err := p.AsFloat64()
+	if err != nil {
+		t.Fatal("Could not AsFloat64:", err.Error())
+	}
+	if f != 1.5 {
+		t.Fatalf(`Expected f == 1.5. Actual f == %v`, f)
+	}
+
+	p.UnmarshalJSON([]byte(`1e400`))
+	_, err = p.AsFloat64()
+	if !errors.Is(err, ipldpolymorph.ErrOverflow) {
+		t.Fatalf("Expected err to be ErrOverflow. Actual err == %v", err)
+	}
+}
+
+func TestAsNumber(t *testing.T) {
+	p := ipldpolymorph.New(ipfsURL)
+	p.UnmarshalJSON([]byte(` 123456789012345678901234567890 `))
+
+	n, err := p.AsNumber()
+	if err != nil {
+		t.Fatal("Could not AsNumber:", err.Error())
+	}
+	if n.String() != "123456789012345678901234567890" {
+		t.Fatalf(`Expected n == 123456789012345678901234567890. Actual n == %v`, n)
+	}
+}
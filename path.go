@@ -0,0 +1,102 @@
+package ipldpolymorph
+
+import (
+	"bytes"
+	"strconv"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// Path addresses a value inside of a Polymorph. Each
+// segment is either an object key or an array index.
+// Segments are stored unescaped, so keys may contain
+// any character, including "/" and "~".
+type Path []string
+
+// ParsePath parses a path string. A path starting with
+// "/" is parsed as an RFC 6901 JSON Pointer, in which "~1"
+// escapes "/" and "~0" escapes "~". Any other path is split
+// on "/" without any escaping, e.g. "foo/bar".
+func ParsePath(path string) (Path, error) {
+	if strings.HasPrefix(path, "/") {
+		return ParsePointer(path)
+	}
+	return Path(strings.Split(path, "/")), nil
+}
+
+// ParsePointer parses an RFC 6901 JSON Pointer. The empty
+// pointer "" addresses the whole document, every other
+// pointer must start with "/".
+func ParsePointer(pointer string) (Path, error) {
+	if pointer == "" {
+		return Path{}, nil
+	}
+	if !strings.HasPrefix(pointer, "/") {
+		return nil, errors.Errorf(`JSON Pointer "%v" must start with "/"`, pointer)
+	}
+
+	pieces := strings.Split(pointer[1:], "/")
+	path := make(Path, len(pieces))
+	for i, piece := range pieces {
+		segment, err := unescapePointerSegment(piece)
+		if err != nil {
+			return nil, errors.Wrapf(err, `invalid JSON Pointer "%v"`, pointer)
+		}
+		path[i] = segment
+	}
+	return path, nil
+}
+
+// Append returns a copy of the path with the
+// given segments added to the end
+func (p Path) Append(segments ...string) Path {
+	path := make(Path, 0, len(p)+len(segments))
+	path = append(path, p...)
+	return append(path, segments...)
+}
+
+// AppendIndex returns a copy of the path with
+// the given array index added to the end
+func (p Path) AppendIndex(index int) Path {
+	return p.Append(strconv.Itoa(index))
+}
+
+// String returns the path as an RFC 6901 JSON Pointer
+func (p Path) String() string {
+	buf := &bytes.Buffer{}
+	for _, segment := range p {
+		buf.WriteString("/")
+		buf.WriteString(pointerEscaper.Replace(segment))
+	}
+	return buf.String()
+}
+
+var pointerEscaper = strings.NewReplacer("~", "~0", "/", "~1")
+
+func unescapePointerSegment(piece string) (string, error) {
+	if !strings.Contains(piece, "~") {
+		return piece, nil
+	}
+
+	buf := &bytes.Buffer{}
+	for i := 0; i < len(piece); i++ {
+		if piece[i] != '~' {
+			buf.WriteByte(piece[i])
+			continue
+		}
+		if i+1 >= len(piece) {
+			return "", errors.New(`"~" must be followed by "0" or "1"`)
+		}
+		switch piece[i+1] {
+		case '0':
+			buf.WriteByte('~')
+		case '1':
+			buf.WriteByte('/')
+		default:
+			return "", errors.New(`"~" must be followed by "0" or "1"`)
+		}
+		i++
+	}
+	return buf.String(), nil
+}
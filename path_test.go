@@ -0,0 +1,94 @@
+package ipldpolymorph_test
+
+import (
+	"reflect"
+	"testing"
+
+	ipldpolymorph "github.com/computes/go-ipld-polymorph"
+)
+
+func TestParsePath(t *testing.T) {
+	path, err := ipldpolymorph.ParsePath("foo/bar")
+	if err != nil {
+		t.Fatal("Could not ParsePath:", err.Error())
+	}
+
+	expected := ipldpolymorph.Path{"foo", "bar"}
+	if !reflect.DeepEqual(path, expected) {
+		t.Fatalf(`Expected path == %#v. Actual path == %#v`, expected, path)
+	}
+}
+
+func TestParsePathPointer(t *testing.T) {
+	path, err := ipldpolymorph.ParsePath("/a~1b/m~0n/")
+	if err != nil {
+		t.Fatal("Could not ParsePath:", err.Error())
+	}
+
+	expected := ipldpolymorph.Path{"a/b", "m~n", ""}
+	if !reflect.DeepEqual(path, expected) {
+		t.Fatalf(`Expected path == %#v. Actual path == %#v`, expected, path)
+	}
+}
+
+func TestParsePointerEmpty(t *testing.T) {
+	path, err := ipldpolymorph.ParsePointer("")
+	if err != nil {
+		t.Fatal("Could not ParsePointer:", err.Error())
+	}
+
+	if len(path) != 0 {
+		t.Fatalf(`Expected path to be empty. Actual path == %#v`, path)
+	}
+}
+
+func TestParsePointerNoLeadingSlash(t *testing.T) {
+	path, err := ipldpolymorph.ParsePointer("foo")
+	if err == nil {
+		t.Fatal("Expected ParsePointer to return an error, received nil")
+	}
+	if path != nil {
+		t.Fatalf(`Expected path == nil. Actual path == %#v`, path)
+	}
+}
+
+func TestParsePointerBadEscape(t *testing.T) {
+	path, err := ipldpolymorph.ParsePointer("/foo~2")
+	if err == nil {
+		t.Fatal("Expected ParsePointer to return an error, received nil")
+	}
+	if path != nil {
+		t.Fatalf(`Expected path == nil. Actual path == %#v`, path)
+	}
+}
+
+func TestPathString(t *testing.T) {
+	path := ipldpolymorph.Path{"a/b"}.Append("m~n").AppendIndex(3)
+
+	if path.String() != "/a~1b/m~0n/3" {
+		t.Fatalf(`Expected path.String() == "/a~1b/m~0n/3". Actual path.String() == "%v"`, path.String())
+	}
+
+	parsed, err := ipldpolymorph.ParsePointer(path.String())
+	if err != nil {
+		t.Fatal("Could not ParsePointer:", err.Error())
+	}
+	if !reflect.DeepEqual(parsed, path) {
+		t.Fatalf(`Expected parsed == %#v. Actual parsed == %#v`, path, parsed)
+	}
+}
+
+func TestPathAppendDoesNotModify(t *testing.T) {
+	base := make(ipldpolymorph.Path, 1, 4)
+	base[0] = "foo"
+
+	first := base.Append("bar")
+	second := base.Append("baz")
+
+	if first[1] != "bar" {
+		t.Fatalf(`Expected first[1] == "bar". Actual first[1] == "%v"`, first[1])
+	}
+	if second[1] != "baz" {
+		t.Fatalf(`Expected second[1] == "baz". Actual second[1] == "%v"`, second[1])
+	}
+}
@@ -1,31 +1,80 @@
 package ipldpolymorph
 
 import (
+	"context"
 	"encoding/json"
 	"net/url"
-	"strings"
+	"reflect"
 
 	"github.com/pkg/errors"
 )
 
 // DefaultIPFSURL can be set to allow Polymorph
-// instantiated to be instantiated without a url
+// instantiated to be instantiated without a url.
+//
+// Deprecated: set DefaultClient.URL, or construct
+// Polymorph instances with a Client instead.
 var DefaultIPFSURL *url.URL
 
+// DefaultMaxLinkHops is the maximum number of IPLD
+// references followed in a single resolution when
+// neither Polymorph.MaxLinkHops nor Client.MaxLinkHops
+// is set
+var DefaultMaxLinkHops = 64
+
 // Polymorph an object that treats IPLD references and
 // raw values the same. It is intended to be constructed
 // with New, and to be JSON Unmarshaled into. Polymorph
 // lazy loads all IPLD references and caches the results,
 // so subsequent calls to a path will have nearly no cost.
+// The Client that constructed a Polymorph, or DefaultClient,
+// configures how IPLD references are resolved. The fields of
+// the Polymorph override that configuration: IPLD references
+// are retrieved from BlockStore when it is set, and from the
+// IPFS API at IPFSURL when that is set. MaxLinkHops limits
+// how many IPLD references a single resolution may follow.
+// Resolution fails with a *LinkChainError when the limit is
+// exceeded or a reference cycle is detected. Resolved
+// references are stored in Cache, which is created on first
+// use if it is not set. Every Polymorph derived from this
+// one, e.g. by GetPolymorph, shares its configuration and
+// its Cache.
 type Polymorph struct {
-	IPFSURL *url.URL
-	raw     json.RawMessage
-	cache   Cache
+	IPFSURL     *url.URL
+	BlockStore  BlockStore
+	Cache       Cache
+	MaxLinkHops int
+	raw         json.RawMessage
+	client      *Client
+
+	// links records the IPLD references that
+	// edits replaced with their content
+	links *linkTrie
 }
 
 // New Constructs a new Polymorph instance
+// using DefaultClient
 func New(ipfsURL *url.URL) *Polymorph {
-	return &Polymorph{IPFSURL: ipfsURL}
+	p := DefaultClient.New()
+	p.IPFSURL = ipfsURL
+	return p
+}
+
+// NewWithCache Constructs a new Polymorph instance that
+// stores resolved IPLD references in cache, which may be
+// shared with other Polymorph instances
+func NewWithCache(ipfsURL *url.URL, cache Cache) *Polymorph {
+	p := New(ipfsURL)
+	p.Cache = cache
+	return p
+}
+
+// NewWithBlockStore Constructs a new Polymorph instance
+// that resolves IPLD references using store
+func NewWithBlockStore(store BlockStore) *Polymorph {
+	p := DefaultClient.New()
+	p.BlockStore = store
+	return p
 }
 
 // FromRef instantiates a new Polymorph instance with a ref
@@ -56,8 +105,14 @@ func FromInterface(ipfsURL *url.URL, data interface{}) (*Polymorph, error) {
 // AsBool returns the current value as a bool,
 // resolving the IPLD reference if necessary
 func (p *Polymorph) AsBool() (bool, error) {
+	return p.AsBoolContext(context.Background())
+}
+
+// AsBoolContext returns the current value as a bool,
+// resolving the IPLD reference if necessary
+func (p *Polymorph) AsBoolContext(ctx context.Context) (bool, error) {
 	var b bool
-	err := p.ToInterface(&b)
+	err := p.ToInterfaceContext(ctx, &b)
 	return b, err
 }
 
@@ -74,167 +129,242 @@ func (p *Polymorph) AsRef() string {
 // AsString returns the current value as a string,
 // resolving the IPLD reference if necessary
 func (p *Polymorph) AsString() (string, error) {
+	return p.AsStringContext(context.Background())
+}
+
+// AsStringContext returns the current value as a string,
+// resolving the IPLD reference if necessary
+func (p *Polymorph) AsStringContext(ctx context.Context) (string, error) {
 	var s string
-	err := p.ToInterface(&s)
+	err := p.ToInterfaceContext(ctx, &s)
 	return s, err
 }
 
 // ToInterface returns the current value and maps it to the
 // given interface, resolving the IPLD reference if necessary
 func (p *Polymorph) ToInterface(data interface{}) error {
-	raw, err := p.AsRawMessage()
+	return p.ToInterfaceContext(context.Background(), data)
+}
+
+// ToInterfaceContext returns the current value and maps it to the
+// given interface, resolving the IPLD reference if necessary.
+// Links decoded into data resolve IPLD references like p does.
+// Returns an error wrapping ErrTypeMismatch if the value can't
+// be stored in data.
+func (p *Polymorph) ToInterfaceContext(ctx context.Context, data interface{}) error {
+	raw, err := p.AsRawMessageContext(ctx)
 	if err != nil {
 		return errors.Wrap(err, "AsRawMessage failed")
 	}
 
 	err = json.Unmarshal(raw, &data)
+	if typeErr, ok := err.(*json.UnmarshalTypeError); ok {
+		return errors.Wrap(ErrTypeMismatch, typeErr.Error())
+	}
 	if err != nil {
 		return errors.Wrap(err, "Unmarshal failed")
 	}
 
+	bindLinks(reflect.ValueOf(data), p)
 	return nil
 }
 
 // CalcRef returns the ref of a raw message by
 // putting it into the dag
 func (p *Polymorph) CalcRef() (string, error) {
+	return p.CalcRefContext(context.Background())
+}
+
+// CalcRefContext returns the ref of a raw message by
+// putting it into the dag
+func (p *Polymorph) CalcRefContext(ctx context.Context) (string, error) {
 	if p.IsRef() {
 		return p.AsRef(), nil
 	}
-	return CalcRef(p.ipfsURL(), p.raw)
+	return CalcRefWithStore(ctx, p.blockStore(), p.raw)
 }
 
 // AsRawMessage returns the current value as a string,
 // resolving the IPLD reference if necessary
 func (p *Polymorph) AsRawMessage() (json.RawMessage, error) {
+	return p.AsRawMessageContext(context.Background())
+}
+
+// AsRawMessageContext returns the current value as a string,
+// resolving the IPLD reference if necessary
+func (p *Polymorph) AsRawMessageContext(ctx context.Context) (json.RawMessage, error) {
 	if !p.IsRef() {
 		return p.raw, nil
 	}
 
-	return ResolveRef(p.ipfsURL(), p.raw, p.getCache())
+	raw, _, err := p.resolve(ctx, p.raw, nil)
+	return raw, err
 }
 
 // GetBool returns the bool value at path, resolving
 // IPLD references if necessary to get there.
 func (p *Polymorph) GetBool(path string) (bool, error) {
-	poly, err := p.GetPolymorph(path)
+	return p.GetBoolContext(context.Background(), path)
+}
+
+// GetBoolContext returns the bool value at path, resolving
+// IPLD references if necessary to get there.
+func (p *Polymorph) GetBoolContext(ctx context.Context, path string) (bool, error) {
+	poly, err := p.GetPolymorphContext(ctx, path)
 	if err != nil {
 		return false, errors.Wrap(err, "GetPolymorph failed")
 	}
 
-	return poly.AsBool()
+	return poly.AsBoolContext(ctx)
 }
 
 // GetPolymorph returns a Polymorph value at path, resolving
 // IPLD references if necessary to get there.
 func (p *Polymorph) GetPolymorph(path string) (*Polymorph, error) {
-	raw, err := p.GetRawMessage(path)
+	return p.GetPolymorphContext(context.Background(), path)
+}
+
+// GetPolymorphContext returns a Polymorph value at path, resolving
+// IPLD references if necessary to get there.
+func (p *Polymorph) GetPolymorphContext(ctx context.Context, path string) (*Polymorph, error) {
+	raw, err := p.GetRawMessageContext(ctx, path)
 	if err != nil {
 		return nil, errors.Wrap(err, "GetRawMessage failed")
 	}
 
-	value := New(p.ipfsURL())
-	_ = value.UnmarshalJSON(raw) // UnmarshalJSON returns an error
-	return value, nil
+	return p.derive(raw), nil
 }
 
-// GetRawMessage returns the raw JSON value at path, resolving
+// GetPolymorphAt returns a Polymorph value at path, resolving
 // IPLD references if necessary to get there.
-func (p *Polymorph) GetRawMessage(path string) (json.RawMessage, error) {
-	var err error
+func (p *Polymorph) GetPolymorphAt(path Path) (*Polymorph, error) {
+	return p.GetPolymorphAtContext(context.Background(), path)
+}
 
-	raw := p.raw
-	if IsRef(raw) {
-		raw, err = ResolveRef(p.ipfsURL(), raw, p.getCache())
-		if err != nil {
-			return nil, errors.Wrap(err, "ResolveRef failed")
-		}
+// GetPolymorphAtContext returns a Polymorph value at path, resolving
+// IPLD references if necessary to get there.
+func (p *Polymorph) GetPolymorphAtContext(ctx context.Context, path Path) (*Polymorph, error) {
+	raw, err := p.GetRawMessageAtContext(ctx, path)
+	if err != nil {
+		return nil, errors.Wrap(err, "GetRawMessageAt failed")
 	}
 
-	for _, pathPiece := range strings.Split(path, "/") {
-		var ok bool
-		parsed := make(map[string]json.RawMessage)
-		err = json.Unmarshal(raw, &parsed)
-		if err != nil {
-			return nil, errors.Wrap(err, "Unmarshal failed")
-		}
+	return p.derive(raw), nil
+}
 
-		raw, ok = parsed[pathPiece]
-		if !ok {
-			return nil, errors.Errorf(`no value found at path "%v"`, path)
-		}
-		if IsRef(raw) {
-			raw, err = ResolveRef(p.ipfsURL(), raw, p.getCache())
-			if err != nil {
-				return nil, errors.Wrap(err, "ResolveRef failed")
-			}
-		}
+// GetRawMessage returns the raw JSON value at path, resolving
+// IPLD references if necessary to get there. The path is
+// parsed with ParsePath, so it may be a JSON Pointer. Numeric
+// path segments index into arrays, negative indexes count back
+// from the end of the array.
+func (p *Polymorph) GetRawMessage(path string) (json.RawMessage, error) {
+	return p.GetRawMessageContext(context.Background(), path)
+}
+
+// GetRawMessageContext returns the raw JSON value at path, resolving
+// IPLD references if necessary to get there.
+func (p *Polymorph) GetRawMessageContext(ctx context.Context, path string) (json.RawMessage, error) {
+	parsed, err := ParsePath(path)
+	if err != nil {
+		return nil, errors.Wrap(err, "ParsePath failed")
 	}
 
-	return raw, nil
+	return p.lookup(ctx, parsed, path, true)
+}
+
+// GetRawMessageAt returns the raw JSON value at path, resolving
+// IPLD references if necessary to get there.
+func (p *Polymorph) GetRawMessageAt(path Path) (json.RawMessage, error) {
+	return p.GetRawMessageAtContext(context.Background(), path)
+}
+
+// GetRawMessageAtContext returns the raw JSON value at path, resolving
+// IPLD references if necessary to get there.
+func (p *Polymorph) GetRawMessageAtContext(ctx context.Context, path Path) (json.RawMessage, error) {
+	return p.lookup(ctx, path, path.String(), true)
 }
 
 // GetUnresolvedPolymorph returns a Polymorph value at path, resolving
 // only the necessary IPLD references to get there.
 func (p *Polymorph) GetUnresolvedPolymorph(path string) (*Polymorph, error) {
-	raw, err := p.GetUnresolvedRawMessage(path)
+	return p.GetUnresolvedPolymorphContext(context.Background(), path)
+}
+
+// GetUnresolvedPolymorphContext returns a Polymorph value at path, resolving
+// only the necessary IPLD references to get there.
+func (p *Polymorph) GetUnresolvedPolymorphContext(ctx context.Context, path string) (*Polymorph, error) {
+	raw, err := p.GetUnresolvedRawMessageContext(ctx, path)
 	if err != nil {
 		return nil, errors.Wrap(err, "GetUnresolvedRawMessage failed")
 	}
 
-	value := New(p.ipfsURL())
-	_ = value.UnmarshalJSON(raw) // UnmarshalJSON returns an error
-	return value, nil
+	return p.derive(raw), nil
 }
 
-// GetUnresolvedRawMessage returns the raw JSON value at path, resolving
+// GetUnresolvedPolymorphAt returns a Polymorph value at path, resolving
 // only the necessary IPLD references to get there.
-func (p *Polymorph) GetUnresolvedRawMessage(path string) (json.RawMessage, error) {
-	var err error
+func (p *Polymorph) GetUnresolvedPolymorphAt(path Path) (*Polymorph, error) {
+	return p.GetUnresolvedPolymorphAtContext(context.Background(), path)
+}
 
-	raw := p.raw
-	if IsRef(raw) {
-		raw, err = ResolveRef(p.ipfsURL(), raw, p.getCache())
-		if err != nil {
-			return nil, errors.Wrap(err, "ResolveRef failed")
-		}
+// GetUnresolvedPolymorphAtContext returns a Polymorph value at path, resolving
+// only the necessary IPLD references to get there.
+func (p *Polymorph) GetUnresolvedPolymorphAtContext(ctx context.Context, path Path) (*Polymorph, error) {
+	raw, err := p.GetUnresolvedRawMessageAtContext(ctx, path)
+	if err != nil {
+		return nil, errors.Wrap(err, "GetUnresolvedRawMessageAt failed")
 	}
 
-	paths := strings.Split(path, "/")
+	return p.derive(raw), nil
+}
 
-	for i, pathPiece := range paths {
-		var ok bool
-		parsed := make(map[string]json.RawMessage)
-		err = json.Unmarshal(raw, &parsed)
-		if err != nil {
-			return nil, errors.Wrap(err, "Unmarshal failed")
-		}
+// GetUnresolvedRawMessage returns the raw JSON value at path, resolving
+// only the necessary IPLD references to get there. The path is
+// parsed with ParsePath, so it may be a JSON Pointer. Numeric
+// path segments index into arrays, negative indexes count back
+// from the end of the array.
+func (p *Polymorph) GetUnresolvedRawMessage(path string) (json.RawMessage, error) {
+	return p.GetUnresolvedRawMessageContext(context.Background(), path)
+}
 
-		raw, ok = parsed[pathPiece]
-		if !ok {
-			return nil, errors.Errorf(`no value found at path "%v"`, path)
-		}
-		// only leave the last part of the path unresolved
-		if i < len(paths)-1 && IsRef(raw) {
-			raw, err = ResolveRef(p.ipfsURL(), raw, p.getCache())
-			if err != nil {
-				return nil, errors.Wrap(err, "ResolveRef failed")
-			}
-		}
+// GetUnresolvedRawMessageContext returns the raw JSON value at path, resolving
+// only the necessary IPLD references to get there.
+func (p *Polymorph) GetUnresolvedRawMessageContext(ctx context.Context, path string) (json.RawMessage, error) {
+	parsed, err := ParsePath(path)
+	if err != nil {
+		return nil, errors.Wrap(err, "ParsePath failed")
 	}
 
-	return raw, nil
+	return p.lookup(ctx, parsed, path, false)
+}
+
+// GetUnresolvedRawMessageAt returns the raw JSON value at path, resolving
+// only the necessary IPLD references to get there.
+func (p *Polymorph) GetUnresolvedRawMessageAt(path Path) (json.RawMessage, error) {
+	return p.GetUnresolvedRawMessageAtContext(context.Background(), path)
+}
+
+// GetUnresolvedRawMessageAtContext returns the raw JSON value at path, resolving
+// only the necessary IPLD references to get there.
+func (p *Polymorph) GetUnresolvedRawMessageAtContext(ctx context.Context, path Path) (json.RawMessage, error) {
+	return p.lookup(ctx, path, path.String(), false)
 }
 
 // GetString returns the string value at path, resolving
 // IPLD references if necessary to get there.
 func (p *Polymorph) GetString(path string) (string, error) {
-	poly, err := p.GetPolymorph(path)
+	return p.GetStringContext(context.Background(), path)
+}
+
+// GetStringContext returns the string value at path, resolving
+// IPLD references if necessary to get there.
+func (p *Polymorph) GetStringContext(ctx context.Context, path string) (string, error) {
+	poly, err := p.GetPolymorphContext(ctx, path)
 	if err != nil {
 		return "", errors.Wrap(err, "GetPolymorph failed")
 	}
 
-	return poly.AsString()
+	return poly.AsStringContext(ctx)
 }
 
 // MarshalJSON returns the original JSON used to
@@ -267,19 +397,101 @@ func (p *Polymorph) IsRef() bool {
 // meet the encoding/json interface requirements.
 func (p *Polymorph) UnmarshalJSON(b []byte) error {
 	p.raw = json.RawMessage(b)
+	p.links = nil
 	return nil
 }
 
+func (p *Polymorph) blockStore() BlockStore {
+	if p.BlockStore == nil {
+		return p.getClient().blockStore(p.IPFSURL)
+	}
+	return p.BlockStore
+}
+
+// derive returns a new Polymorph for raw that resolves
+// IPLD references the same way p does, sharing its Cache.
+func (p *Polymorph) derive(raw json.RawMessage) *Polymorph {
+	value := &Polymorph{
+		IPFSURL:     p.IPFSURL,
+		BlockStore:  p.BlockStore,
+		Cache:       p.getCache(),
+		MaxLinkHops: p.MaxLinkHops,
+		client:      p.client,
+	}
+	_ = value.UnmarshalJSON(raw) // UnmarshalJSON never returns an error
+	return value
+}
+
 func (p *Polymorph) getCache() Cache {
-	if p.cache == nil {
-		p.cache = NewSimpleCache()
+	if p.Cache == nil {
+		p.Cache = NewSimpleCache()
+	}
+	return p.Cache
+}
+
+// lookup walks path from the root of p and returns the raw JSON
+// found there. IPLD references along the way are resolved, the
+// value at the end of the path is only resolved if resolveLast
+// is true. display is the path as the caller wrote it, and is
+// used in error messages. Failures are returned as a
+// *ResolutionError.
+func (p *Polymorph) lookup(ctx context.Context, path Path, display string, resolveLast bool) (json.RawMessage, error) {
+	var err error
+	var chain []string
+	ref := ""
+
+	fail := func(index int, err error) error {
+		return &ResolutionError{Path: path, Index: index, Ref: ref, Err: err, display: display}
+	}
+
+	raw := p.raw
+	for i, segment := range path {
+		if IsRef(raw) {
+			ref, _ = AssertRef(raw)
+			raw, chain, err = p.resolve(ctx, raw, chain)
+			if err != nil {
+				return nil, fail(i, err)
+			}
+		}
+
+		var ok bool
+		raw, ok, err = lookupChild(raw, segment)
+		if err != nil {
+			return nil, fail(i, err)
+		}
+		if !ok {
+			return nil, fail(i, ErrPathNotFound)
+		}
+	}
+
+	if resolveLast && IsRef(raw) {
+		ref, _ = AssertRef(raw)
+		raw, _, err = p.resolve(ctx, raw, chain)
+		if err != nil {
+			return nil, fail(len(path), err)
+		}
+	}
+
+	return raw, nil
+}
+
+// resolve follows the IPLD reference raw, and any references it
+// resolves to. chain holds the refs followed to get to raw, the
+// returned chain has the newly followed refs appended.
+func (p *Polymorph) resolve(ctx context.Context, raw json.RawMessage, chain []string) (json.RawMessage, []string, error) {
+	return followRefs(ctx, p.blockStore(), p.getCache(), raw, chain, p.maxLinkHops())
+}
+
+func (p *Polymorph) maxLinkHops() int {
+	if p.MaxLinkHops <= 0 {
+		return p.getClient().maxLinkHops()
 	}
-	return p.cache
+	return p.MaxLinkHops
 }
 
-func (p *Polymorph) ipfsURL() *url.URL {
-	if p.IPFSURL == nil {
-		return DefaultIPFSURL
+func (p *Polymorph) getClient() *Client {
+	if p.client == nil {
+		return DefaultClient
 	}
-	return p.IPFSURL
+	return p.client
 }
@@ -1,13 +1,16 @@
 package ipldpolymorph_test
 
 import (
+	"context"
 	"encoding/json"
 	"net/http"
 	"net/url"
+	"reflect"
 	"strings"
 	"testing"
 
 	ipldpolymorph "github.com/computes/go-ipld-polymorph"
+	"github.com/pkg/errors"
 )
 
 func BenchmarkAsBool(b *testing.B) {
@@ -660,3 +663,348 @@ func TestGetUnresolvedPolymorphRootRefMissing(t *testing.T) {
 		t.Fatal(`Expected foo == nil, Actual foo == `, foo)
 	}
 }
+
+func TestGetStringContextCancelled(t *testing.T) {
+	beforeEach()
+	httpResponses[http.MethodGet]["/api/v0/dag/get?arg=address-of-foo"] = `"bar"`
+
+	p := ipldpolymorph.New(ipfsURL)
+	p.UnmarshalJSON([]byte(`{"foo": {"/": "address-of-foo"}}`))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	foo, err := p.GetStringContext(ctx, "foo")
+	if err == nil {
+		t.Fatal("Expected GetStringContext to return an error, received nil")
+	}
+	if foo != "" {
+		t.Fatalf(`Expected foo == "". Actual foo == "%v"`, foo)
+	}
+}
+
+func TestGetStringNestedIPLDBlockStore(t *testing.T) {
+	beforeEach()
+	store := ipldpolymorph.NewMemoryBlockStore()
+	barRef, err := store.Put(context.Background(), json.RawMessage(`"red"`))
+	if err != nil {
+		t.Fatal("Could not Put bar:", err.Error())
+	}
+	fooRef, err := store.Put(context.Background(), json.RawMessage(`{"bar": {"/": "`+barRef+`"}}`))
+	if err != nil {
+		t.Fatal("Could not Put foo:", err.Error())
+	}
+
+	p := ipldpolymorph.NewWithBlockStore(store)
+	p.UnmarshalJSON([]byte(`{"foo": {"/": "` + fooRef + `"}}`))
+
+	bar, err := p.GetString("foo/bar")
+	if err != nil {
+		t.Fatal(`Could not GetString for path "foo/bar":`, err.Error())
+	}
+
+	if bar != "red" {
+		t.Fatalf(`Expected bar == "red". Actual bar == "%v"`, bar)
+	}
+}
+
+func TestCalcRefBlockStore(t *testing.T) {
+	beforeEach()
+	store := ipldpolymorph.NewMemoryBlockStore()
+
+	p := ipldpolymorph.NewWithBlockStore(store)
+	p.UnmarshalJSON([]byte(`{"foo": "bar"}`))
+
+	ref, err := p.CalcRef()
+	if err != nil {
+		t.Fatal("Could not CalcRef:", err.Error())
+	}
+
+	loaded := ipldpolymorph.NewWithBlockStore(store)
+	loaded.UnmarshalJSON([]byte(`{"/": "` + ref + `"}`))
+	foo, err := loaded.GetString("foo")
+	if err != nil {
+		t.Fatal(`Could not GetString for path "foo":`, err.Error())
+	}
+	if foo != "bar" {
+		t.Fatalf(`Expected foo == "bar". Actual foo == "%v"`, foo)
+	}
+}
+
+func TestGetStringArrayIndex(t *testing.T) {
+	beforeEach()
+	p := ipldpolymorph.New(ipfsURL)
+	p.UnmarshalJSON([]byte(`{"tasks": [{"input": "a"}, {"input": "b"}]}`))
+
+	input, err := p.GetString("tasks/1/input")
+	if err != nil {
+		t.Fatal(`Could not GetString for path "tasks/1/input":`, err.Error())
+	}
+
+	if input != "b" {
+		t.Fatalf(`Expected input == "b". Actual input == "%v"`, input)
+	}
+}
+
+func TestGetStringArrayNegativeIndex(t *testing.T) {
+	beforeEach()
+	p := ipldpolymorph.New(ipfsURL)
+	p.UnmarshalJSON([]byte(`{"tasks": ["a", "b", "c"]}`))
+
+	task, err := p.GetString("tasks/-1")
+	if err != nil {
+		t.Fatal(`Could not GetString for path "tasks/-1":`, err.Error())
+	}
+
+	if task != "c" {
+		t.Fatalf(`Expected task == "c". Actual task == "%v"`, task)
+	}
+}
+
+func TestGetStringArrayIndexOutOfRange(t *testing.T) {
+	beforeEach()
+	p := ipldpolymorph.New(ipfsURL)
+	p.UnmarshalJSON([]byte(`{"tasks": ["a", "b", "c"]}`))
+
+	task, err := p.GetString("tasks/3")
+	if err == nil {
+		t.Fatal("Expected GetString to return an error, received nil")
+	}
+	if !strings.Contains(err.Error(), `no value found at path "tasks/3"`) {
+		t.Fatal("Expected error to mention missing value.", err.Error())
+	}
+	if task != "" {
+		t.Fatalf(`Expected task == "". Actual task == "%v"`, task)
+	}
+}
+
+func TestGetStringArrayIPLD(t *testing.T) {
+	beforeEach()
+	httpResponses[http.MethodGet]["/api/v0/dag/get?arg=tasks-addr"] = `[{"/": "task-0-addr"}, {"/": "task-1-addr"}]`
+	httpResponses[http.MethodGet]["/api/v0/dag/get?arg=task-1-addr"] = `{"input": "b"}`
+
+	p := ipldpolymorph.New(ipfsURL)
+	p.UnmarshalJSON([]byte(`{"tasks": {"/": "tasks-addr"}}`))
+
+	input, err := p.GetString("tasks/1/input")
+	if err != nil {
+		t.Fatal(`Could not GetString for path "tasks/1/input":`, err.Error())
+	}
+
+	if input != "b" {
+		t.Fatalf(`Expected input == "b". Actual input == "%v"`, input)
+	}
+}
+
+func TestGetUnresolvedPolymorphArrayIPLD(t *testing.T) {
+	beforeEach()
+	httpResponses[http.MethodGet]["/api/v0/dag/get?arg=tasks-addr"] = `[{"/": "task-0-addr"}, {"/": "task-1-addr"}]`
+
+	p := ipldpolymorph.New(ipfsURL)
+	p.UnmarshalJSON([]byte(`{"tasks": {"/": "tasks-addr"}}`))
+
+	task, err := p.GetUnresolvedPolymorph("tasks/-2")
+	if err != nil {
+		t.Fatal(`Could not GetUnresolvedPolymorph for path "tasks/-2":`, err.Error())
+	}
+
+	if task.AsRef() != "task-0-addr" {
+		t.Fatalf(`Expected task.AsRef() == "task-0-addr". Actual task.AsRef() == "%v"`, task.AsRef())
+	}
+}
+
+func TestGetStringPointer(t *testing.T) {
+	beforeEach()
+	httpResponses[http.MethodGet]["/api/v0/dag/get?arg=foo-addr"] = `{"a/b": {"m~n": {"/": "bar-addr"}}}`
+	httpResponses[http.MethodGet]["/api/v0/dag/get?arg=bar-addr"] = `"red"`
+
+	p := ipldpolymorph.New(ipfsURL)
+	p.UnmarshalJSON([]byte(`{"foo": {"/": "foo-addr"}}`))
+
+	bar, err := p.GetString("/foo/a~1b/m~0n")
+	if err != nil {
+		t.Fatal(`Could not GetString for path "/foo/a~1b/m~0n":`, err.Error())
+	}
+
+	if bar != "red" {
+		t.Fatalf(`Expected bar == "red". Actual bar == "%v"`, bar)
+	}
+}
+
+func TestGetStringPointerBadEscape(t *testing.T) {
+	beforeEach()
+	p := ipldpolymorph.New(ipfsURL)
+	p.UnmarshalJSON([]byte(`{"foo": "bar"}`))
+
+	foo, err := p.GetString("/foo~")
+	if err == nil {
+		t.Fatal("Expected GetString to return an error, received nil")
+	}
+	if foo != "" {
+		t.Fatalf(`Expected foo == "". Actual foo == "%v"`, foo)
+	}
+}
+
+func TestGetRawMessageAtEmptyKey(t *testing.T) {
+	beforeEach()
+	p := ipldpolymorph.New(ipfsURL)
+	p.UnmarshalJSON([]byte(`{"": {"a/b": 1}}`))
+
+	raw, err := p.GetRawMessageAt(ipldpolymorph.Path{"", "a/b"})
+	if err != nil {
+		t.Fatal(`Could not GetRawMessageAt:`, err.Error())
+	}
+
+	if string(raw) != `1` {
+		t.Fatalf(`Expected raw == 1. Actual raw == %s`, raw)
+	}
+}
+
+func TestGetRawMessageAtRoot(t *testing.T) {
+	beforeEach()
+	httpResponses[http.MethodGet]["/api/v0/dag/get?arg=foo"] = `{"bar":"red"}`
+	p := ipldpolymorph.FromRef(ipfsURL, "foo")
+
+	raw, err := p.GetRawMessageAt(ipldpolymorph.Path{})
+	if err != nil {
+		t.Fatal(`Could not GetRawMessageAt:`, err.Error())
+	}
+	if string(raw) != `{"bar":"red"}` {
+		t.Fatalf(`Expected raw == {"bar":"red"}. Actual raw == %s`, raw)
+	}
+
+	unresolved, err := p.GetUnresolvedRawMessageAt(ipldpolymorph.Path{})
+	if err != nil {
+		t.Fatal(`Could not GetUnresolvedRawMessageAt:`, err.Error())
+	}
+	if string(unresolved) != `{"/":"foo"}` {
+		t.Fatalf(`Expected unresolved == {"/":"foo"}. Actual unresolved == %s`, unresolved)
+	}
+}
+
+func TestAsStringRefToRef(t *testing.T) {
+	beforeEach()
+	httpResponses[http.MethodGet]["/api/v0/dag/get?arg=name"] = `{"/": "foo"}`
+	httpResponses[http.MethodGet]["/api/v0/dag/get?arg=foo"] = `"bar"`
+	p := ipldpolymorph.FromRef(ipfsURL, "name")
+
+	foo, err := p.AsString()
+	if err != nil {
+		t.Fatal(`Could not AsString:`, err.Error())
+	}
+
+	if foo != "bar" {
+		t.Fatalf(`Expected foo == "bar". Actual foo == "%v"`, foo)
+	}
+}
+
+func TestAsStringRefCycle(t *testing.T) {
+	beforeEach()
+	httpResponses[http.MethodGet]["/api/v0/dag/get?arg=a"] = `{"/": "b"}`
+	httpResponses[http.MethodGet]["/api/v0/dag/get?arg=b"] = `{"/": "a"}`
+	p := ipldpolymorph.FromRef(ipfsURL, "a")
+
+	_, err := p.AsString()
+	chainErr, ok := errors.Cause(err).(*ipldpolymorph.LinkChainError)
+	if !ok {
+		t.Fatal("Expected AsString to return a LinkChainError, received", err)
+	}
+
+	if !chainErr.Cycle {
+		t.Fatal("Expected chainErr.Cycle to be true, was false")
+	}
+	expected := []string{"a", "b", "a"}
+	if !reflect.DeepEqual(chainErr.Chain, expected) {
+		t.Fatalf(`Expected chainErr.Chain == %v. Actual chainErr.Chain == %v`, expected, chainErr.Chain)
+	}
+}
+
+func TestGetStringPathCycle(t *testing.T) {
+	beforeEach()
+	httpResponses[http.MethodGet]["/api/v0/dag/get?arg=a"] = `{"self": {"/": "a"}, "name": "a"}`
+	p := ipldpolymorph.FromRef(ipfsURL, "a")
+
+	_, err := p.GetString("self/self/name")
+	chainErr, ok := errors.Cause(err).(*ipldpolymorph.LinkChainError)
+	if !ok {
+		t.Fatal("Expected GetString to return a LinkChainError, received", err)
+	}
+
+	if !chainErr.Cycle {
+		t.Fatal("Expected chainErr.Cycle to be true, was false")
+	}
+}
+
+func TestGetStringMaxLinkHops(t *testing.T) {
+	beforeEach()
+	httpResponses[http.MethodGet]["/api/v0/dag/get?arg=a"] = `{"b": {"/": "b"}}`
+	httpResponses[http.MethodGet]["/api/v0/dag/get?arg=b"] = `{"c": {"/": "c"}}`
+	httpResponses[http.MethodGet]["/api/v0/dag/get?arg=c"] = `"red"`
+	p := ipldpolymorph.FromRef(ipfsURL, "a")
+	p.MaxLinkHops = 2
+
+	_, err := p.GetString("b/c")
+	chainErr, ok := errors.Cause(err).(*ipldpolymorph.LinkChainError)
+	if !ok {
+		t.Fatal("Expected GetString to return a LinkChainError, received", err)
+	}
+
+	if chainErr.Cycle {
+		t.Fatal("Expected chainErr.Cycle to be false, was true")
+	}
+	expected := []string{"a", "b", "c"}
+	if !reflect.DeepEqual(chainErr.Chain, expected) {
+		t.Fatalf(`Expected chainErr.Chain == %v. Actual chainErr.Chain == %v`, expected, chainErr.Chain)
+	}
+}
+
+func TestNewWithCache(t *testing.T) {
+	beforeEach()
+	cache := ipldpolymorph.NewSimpleCache()
+	cache.Set("foo", json.RawMessage(`"bar"`))
+
+	p := ipldpolymorph.NewWithCache(ipfsURL, cache)
+	p.UnmarshalJSON([]byte(`{"/": "foo"}`))
+
+	foo, err := p.AsString()
+	if err != nil {
+		t.Fatal(`Could not AsString:`, err.Error())
+	}
+
+	if foo != "bar" {
+		t.Fatalf(`Expected foo == "bar". Actual foo == "%v"`, foo)
+	}
+}
+
+func TestGetUnresolvedPolymorphSharesCache(t *testing.T) {
+	beforeEach()
+	httpResponses[http.MethodGet]["/api/v0/dag/get?arg=foo-addr"] = `{"bar": {"/": "bar-addr"}}`
+	httpResponses[http.MethodGet]["/api/v0/dag/get?arg=bar-addr"] = `"red"`
+
+	p := ipldpolymorph.New(ipfsURL)
+	p.UnmarshalJSON([]byte(`{"foo": {"/": "foo-addr"}}`))
+
+	_, err := p.GetString("foo/bar")
+	if err != nil {
+		t.Fatal(`Could not GetString for path "foo/bar":`, err.Error())
+	}
+
+	delete(httpResponses[http.MethodGet], "/api/v0/dag/get?arg=foo-addr")
+	delete(httpResponses[http.MethodGet], "/api/v0/dag/get?arg=bar-addr")
+
+	foo, err := p.GetUnresolvedPolymorph("foo")
+	if err != nil {
+		t.Fatal(`Could not GetUnresolvedPolymorph for path "foo":`, err.Error())
+	}
+
+	bar, err := foo.GetString("bar")
+	if err != nil {
+		t.Fatal(`Could not GetString for path "bar":`, err.Error())
+	}
+	if bar != "red" {
+		t.Fatalf(`Expected bar == "red". Actual bar == "%v"`, bar)
+	}
+	if foo.Cache != p.Cache {
+		t.Fatal("Expected foo to share the Cache of p")
+	}
+}
@@ -0,0 +1,232 @@
+package ipldpolymorph
+
+import (
+	"context"
+	"encoding/json"
+	"sync"
+
+	"github.com/pkg/errors"
+)
+
+// DefaultConcurrency is the number of IPLD references
+// that are fetched in parallel when no concurrency
+// is configured
+var DefaultConcurrency = 8
+
+// ResolveOptions configures ResolveAll
+type ResolveOptions struct {
+	// MaxDepth is the maximum number of IPLD references
+	// followed along any single chain of references.
+	// Deeper references are left as they are. Zero
+	// means there is no limit.
+	MaxDepth int
+
+	// Concurrency is the maximum number of IPLD
+	// references fetched in parallel, and of extra
+	// goroutines walking the document. Zero means
+	// the Client's Concurrency is used.
+	Concurrency int
+}
+
+// ResolveAll returns the current value as a single JSON
+// document in which every reachable IPLD reference has been
+// replaced by its content. Independent sibling references are
+// fetched concurrently. A *LinkChainError is returned if a
+// chain of references loops back on itself or is longer than
+// the Polymorph's MaxLinkHops.
+func (p *Polymorph) ResolveAll(opts ResolveOptions) (json.RawMessage, error) {
+	return p.ResolveAllContext(context.Background(), opts)
+}
+
+// ResolveAllContext returns the current value as a single JSON
+// document in which every reachable IPLD reference has been
+// replaced by its content. Independent sibling references are
+// fetched concurrently.
+func (p *Polymorph) ResolveAllContext(ctx context.Context, opts ResolveOptions) (json.RawMessage, error) {
+	if p.raw == nil {
+		return nil, errors.Errorf("Polymorph.raw is nil")
+	}
+
+	concurrency := opts.Concurrency
+	if concurrency <= 0 {
+		concurrency = p.getClient().concurrency()
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	resolver := &allResolver{
+		store:       p.blockStore(),
+		cache:       p.getCache(),
+		maxDepth:    opts.MaxDepth,
+		maxLinkHops: p.maxLinkHops(),
+		fetches:     make(chan struct{}, concurrency),
+		workers:     make(chan struct{}, concurrency),
+	}
+	raw, _, err := resolver.resolve(ctx, p.raw, nil)
+	return raw, err
+}
+
+// allResolver holds the state shared by every
+// branch of a single ResolveAll call
+type allResolver struct {
+	store       BlockStore
+	cache       Cache
+	maxDepth    int
+	maxLinkHops int
+	fetches     chan struct{}
+	workers     chan struct{}
+}
+
+// resolve returns raw with every IPLD reference inlined.
+// chain holds the refs followed to get to raw. The boolean
+// is false if raw was returned unmodified.
+func (r *allResolver) resolve(ctx context.Context, raw json.RawMessage, chain []string) (json.RawMessage, bool, error) {
+	if IsRef(raw) {
+		if r.maxDepth > 0 && len(chain) >= r.maxDepth {
+			return raw, false, nil
+		}
+
+		value, chain, err := r.fetch(ctx, raw, chain)
+		if err != nil {
+			return nil, false, err
+		}
+
+		value, _, err = r.resolve(ctx, value, chain)
+		if err != nil {
+			return nil, false, err
+		}
+		return value, true, nil
+	}
+
+	switch firstByte(raw) {
+	case '{':
+		parsed := make(map[string]json.RawMessage)
+		if err := json.Unmarshal(raw, &parsed); err != nil {
+			return nil, false, errors.Wrap(err, "Unmarshal failed")
+		}
+
+		keys := make([]string, 0, len(parsed))
+		values := make([]json.RawMessage, 0, len(parsed))
+		for key, value := range parsed {
+			keys = append(keys, key)
+			values = append(values, value)
+		}
+
+		changed, err := r.resolveEach(ctx, values, chain)
+		if err != nil {
+			return nil, false, err
+		}
+		if !changed {
+			return raw, false, nil
+		}
+
+		for i, key := range keys {
+			parsed[key] = values[i]
+		}
+		return marshalChanged(parsed)
+	case '[':
+		var parsed []json.RawMessage
+		if err := json.Unmarshal(raw, &parsed); err != nil {
+			return nil, false, errors.Wrap(err, "Unmarshal failed")
+		}
+
+		changed, err := r.resolveEach(ctx, parsed, chain)
+		if err != nil {
+			return nil, false, err
+		}
+		if !changed {
+			return raw, false, nil
+		}
+		return marshalChanged(parsed)
+	}
+
+	return raw, false, nil
+}
+
+// resolveEach resolves all values, replacing each value in
+// place. Returns true if any value changed. Scalars need no
+// work and are skipped. The other values are handed to a new
+// goroutine while a worker slot is free, and are resolved by
+// the calling goroutine otherwise, which bounds the number of
+// goroutines without ever waiting on a slot.
+func (r *allResolver) resolveEach(ctx context.Context, values []json.RawMessage, chain []string) (bool, error) {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	var wg sync.WaitGroup
+	var mutex sync.Mutex
+	var firstErr error
+	changed := false
+
+	resolveValue := func(i int) {
+		value, valueChanged, err := r.resolve(ctx, values[i], chain)
+
+		mutex.Lock()
+		defer mutex.Unlock()
+		if err != nil {
+			if firstErr == nil {
+				firstErr = err
+				cancel()
+			}
+			return
+		}
+		if valueChanged {
+			values[i] = value
+			changed = true
+		}
+	}
+
+	for i := range values {
+		if ctx.Err() != nil {
+			break
+		}
+		if !isContainer(values[i]) {
+			continue
+		}
+
+		select {
+		case r.workers <- struct{}{}:
+			wg.Add(1)
+			go func(i int) {
+				defer wg.Done()
+				defer func() { <-r.workers }()
+				resolveValue(i)
+			}(i)
+		default:
+			resolveValue(i)
+		}
+	}
+	wg.Wait()
+
+	if firstErr == nil && ctx.Err() != nil {
+		// the caller's ctx was cancelled before every value was resolved
+		return false, ctx.Err()
+	}
+	return changed, firstErr
+}
+
+// fetch follows the IPLD reference raw, waiting for
+// a free slot if too many fetches are in flight
+func (r *allResolver) fetch(ctx context.Context, raw json.RawMessage, chain []string) (json.RawMessage, []string, error) {
+	select {
+	case r.fetches <- struct{}{}:
+	case <-ctx.Done():
+		return nil, nil, ctx.Err()
+	}
+	defer func() { <-r.fetches }()
+
+	value, chain, err := followRefs(ctx, r.store, r.cache, raw, chain, r.maxLinkHops)
+	if err != nil {
+		return nil, nil, errors.Wrap(err, "ResolveRef failed")
+	}
+	return value, chain, nil
+}
+
+func marshalChanged(value interface{}) (json.RawMessage, bool, error) {
+	buf, err := json.Marshal(value)
+	if err != nil {
+		return nil, false, errors.Wrap(err, "Marshal failed")
+	}
+	return json.RawMessage(buf), true, nil
+}
@@ -0,0 +1,228 @@
+package ipldpolymorph_test
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"runtime"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+
+	ipldpolymorph "github.com/computes/go-ipld-polymorph"
+	"github.com/pkg/errors"
+)
+
+// slowBlockStore wraps a BlockStore, delaying every Get
+// and recording the highest number of concurrent Gets
+type slowBlockStore struct {
+	ipldpolymorph.BlockStore
+	mutex       sync.Mutex
+	inFlight    int
+	maxInFlight int
+}
+
+func (s *slowBlockStore) Get(ctx context.Context, ref string) (json.RawMessage, error) {
+	s.mutex.Lock()
+	s.inFlight++
+	if s.inFlight > s.maxInFlight {
+		s.maxInFlight = s.inFlight
+	}
+	s.mutex.Unlock()
+
+	time.Sleep(10 * time.Millisecond)
+
+	s.mutex.Lock()
+	s.inFlight--
+	s.mutex.Unlock()
+	return s.BlockStore.Get(ctx, ref)
+}
+
+func TestResolveAll(t *testing.T) {
+	beforeEach()
+	httpResponses[http.MethodGet]["/api/v0/dag/get?arg=root-addr"] = `{"foo": {"/": "foo-addr"}, "list": [{"/": "bar-addr"}, 2]}`
+	httpResponses[http.MethodGet]["/api/v0/dag/get?arg=foo-addr"] = `{"bar": {"/": "bar-addr"}}`
+	httpResponses[http.MethodGet]["/api/v0/dag/get?arg=bar-addr"] = `"red"`
+
+	p := ipldpolymorph.FromRef(ipfsURL, "root-addr")
+
+	raw, err := p.ResolveAll(ipldpolymorph.ResolveOptions{})
+	if err != nil {
+		t.Fatal("Could not ResolveAll:", err.Error())
+	}
+
+	expected := `{"foo":{"bar":"red"},"list":["red",2]}`
+	if string(raw) != expected {
+		t.Fatalf(`Expected raw == %v. Actual raw == %s`, expected, raw)
+	}
+}
+
+func TestResolveAllNoRefs(t *testing.T) {
+	beforeEach()
+	p := ipldpolymorph.New(ipfsURL)
+	p.UnmarshalJSON([]byte(`{"foo": [1, 2], "bar": "red"}`))
+
+	raw, err := p.ResolveAll(ipldpolymorph.ResolveOptions{})
+	if err != nil {
+		t.Fatal("Could not ResolveAll:", err.Error())
+	}
+
+	if string(raw) != `{"foo": [1, 2], "bar": "red"}` {
+		t.Fatalf(`Expected raw to be unmodified. Actual raw == %s`, raw)
+	}
+}
+
+func TestResolveAllMaxDepth(t *testing.T) {
+	beforeEach()
+	httpResponses[http.MethodGet]["/api/v0/dag/get?arg=foo-addr"] = `{"bar": {"/": "bar-addr"}}`
+
+	p := ipldpolymorph.New(ipfsURL)
+	p.UnmarshalJSON([]byte(`{"foo": {"/": "foo-addr"}}`))
+
+	raw, err := p.ResolveAll(ipldpolymorph.ResolveOptions{MaxDepth: 1})
+	if err != nil {
+		t.Fatal("Could not ResolveAll:", err.Error())
+	}
+
+	expected := `{"foo":{"bar":{"/":"bar-addr"}}}`
+	if string(raw) != expected {
+		t.Fatalf(`Expected raw == %v. Actual raw == %s`, expected, raw)
+	}
+}
+
+func TestResolveAllMissingRef(t *testing.T) {
+	beforeEach()
+	p := ipldpolymorph.New(ipfsURL)
+	p.UnmarshalJSON([]byte(`{"foo": {"/": "foo-addr"}, "bar": 1}`))
+
+	raw, err := p.ResolveAll(ipldpolymorph.ResolveOptions{})
+	if err == nil {
+		t.Fatal("Expected ResolveAll to return an error, received nil")
+	}
+	if raw != nil {
+		t.Fatalf(`Expected raw == nil. Actual raw == %s`, raw)
+	}
+}
+
+func TestResolveAllConcurrency(t *testing.T) {
+	memory := ipldpolymorph.NewMemoryBlockStore()
+	refs := make([]map[string]string, 6)
+	for i := range refs {
+		ref, err := memory.Put(context.Background(), json.RawMessage(`{"index":`+string(rune('0'+i))+`}`))
+		if err != nil {
+			t.Fatal("Could not Put:", err.Error())
+		}
+		refs[i] = map[string]string{"/": ref}
+	}
+
+	store := &slowBlockStore{BlockStore: memory}
+	p := ipldpolymorph.NewWithBlockStore(store)
+	buf, _ := json.Marshal(refs)
+	p.UnmarshalJSON(buf)
+
+	raw, err := p.ResolveAll(ipldpolymorph.ResolveOptions{Concurrency: 2})
+	if err != nil {
+		t.Fatal("Could not ResolveAll:", err.Error())
+	}
+
+	expected := `[{"index":0},{"index":1},{"index":2},{"index":3},{"index":4},{"index":5}]`
+	if string(raw) != expected {
+		t.Fatalf(`Expected raw == %v. Actual raw == %s`, expected, raw)
+	}
+	if store.maxInFlight != 2 {
+		t.Fatalf(`Expected maxInFlight == 2. Actual maxInFlight == %v`, store.maxInFlight)
+	}
+}
+
+// goroutineBlockStore wraps a BlockStore, recording
+// the highest number of goroutines seen by any Get
+type goroutineBlockStore struct {
+	ipldpolymorph.BlockStore
+	mutex         sync.Mutex
+	maxGoroutines int
+}
+
+func (s *goroutineBlockStore) Get(ctx context.Context, ref string) (json.RawMessage, error) {
+	s.mutex.Lock()
+	if count := runtime.NumGoroutine(); count > s.maxGoroutines {
+		s.maxGoroutines = count
+	}
+	s.mutex.Unlock()
+
+	time.Sleep(time.Millisecond)
+	return s.BlockStore.Get(ctx, ref)
+}
+
+func TestResolveAllBoundsGoroutines(t *testing.T) {
+	memory := ipldpolymorph.NewMemoryBlockStore()
+	values := make([]interface{}, 0, 10200)
+	for i := 0; i < 200; i++ {
+		ref, err := memory.Put(context.Background(), json.RawMessage(strconv.Itoa(i)))
+		if err != nil {
+			t.Fatal("Could not Put:", err.Error())
+		}
+		values = append(values, map[string]interface{}{"value": map[string]string{"/": ref}})
+	}
+	for i := 0; i < 10000; i++ {
+		values = append(values, i)
+	}
+
+	store := &goroutineBlockStore{BlockStore: memory}
+	p := ipldpolymorph.NewWithBlockStore(store)
+	buf, _ := json.Marshal(values)
+	p.UnmarshalJSON(buf)
+
+	before := runtime.NumGoroutine()
+	raw, err := p.ResolveAll(ipldpolymorph.ResolveOptions{Concurrency: 2})
+	if err != nil {
+		t.Fatal("Could not ResolveAll:", err.Error())
+	}
+
+	var resolved []interface{}
+	if err := json.Unmarshal(raw, &resolved); err != nil {
+		t.Fatal("Could not Unmarshal:", err.Error())
+	}
+	if len(resolved) != len(values) {
+		t.Fatalf(`Expected len(resolved) == %v. Actual len(resolved) == %v`, len(values), len(resolved))
+	}
+	if extra := store.maxGoroutines - before; extra > 4 {
+		t.Fatalf(`Expected at most 4 extra goroutines. Actual extra goroutines == %v`, extra)
+	}
+}
+
+func TestResolveAllCycle(t *testing.T) {
+	beforeEach()
+	httpResponses[http.MethodGet]["/api/v0/dag/get?arg=a"] = `{"b": {"/": "b"}}`
+	httpResponses[http.MethodGet]["/api/v0/dag/get?arg=b"] = `[{"/": "a"}]`
+	p := ipldpolymorph.FromRef(ipfsURL, "a")
+
+	raw, err := p.ResolveAll(ipldpolymorph.ResolveOptions{})
+	chainErr, ok := errors.Cause(err).(*ipldpolymorph.LinkChainError)
+	if !ok {
+		t.Fatal("Expected ResolveAll to return a LinkChainError, received", err)
+	}
+	if !chainErr.Cycle {
+		t.Fatal("Expected chainErr.Cycle to be true, was false")
+	}
+	if raw != nil {
+		t.Fatalf(`Expected raw == nil. Actual raw == %s`, raw)
+	}
+}
+
+func TestResolveAllSharedSiblings(t *testing.T) {
+	beforeEach()
+	httpResponses[http.MethodGet]["/api/v0/dag/get?arg=a"] = `"red"`
+	p := ipldpolymorph.New(ipfsURL)
+	p.UnmarshalJSON([]byte(`{"foo": {"/": "a"}, "bar": {"/": "a"}}`))
+
+	raw, err := p.ResolveAll(ipldpolymorph.ResolveOptions{})
+	if err != nil {
+		t.Fatal("Could not ResolveAll:", err.Error())
+	}
+
+	expected := `{"bar":"red","foo":"red"}`
+	if string(raw) != expected {
+		t.Fatalf(`Expected raw == %v. Actual raw == %s`, expected, raw)
+	}
+}
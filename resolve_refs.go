@@ -0,0 +1,89 @@
+package ipldpolymorph
+
+import (
+	"context"
+	"encoding/json"
+	"net/url"
+	"sync"
+)
+
+// RefResult holds the outcome of resolving
+// a single ref as part of a batch
+type RefResult struct {
+	Value json.RawMessage
+	Err   error
+}
+
+// ResolveRefs resolves every ref using the IPFS API at
+// ipfsURL, as configured by DefaultClient. See
+// ResolveRefsWithStore for details.
+func ResolveRefs(ctx context.Context, ipfsURL *url.URL, refs []string, cache Cache) map[string]RefResult {
+	return ResolveRefsWithStore(ctx, DefaultClient.blockStore(ipfsURL), refs, cache, DefaultClient.concurrency())
+}
+
+// ResolveRefsWithStore resolves every ref by retrieving it from
+// store, with at most concurrency refs being fetched at once by
+// as many goroutines.
+// Refs found in the cache are not fetched. The results are keyed
+// by ref, and a ref that could not be resolved has its error set
+// in its RefResult without affecting the rest of the batch.
+func ResolveRefsWithStore(ctx context.Context, store BlockStore, refs []string, cache Cache, concurrency int) map[string]RefResult {
+	if concurrency <= 0 {
+		concurrency = DefaultConcurrency
+	}
+
+	results := make(map[string]RefResult, len(refs))
+	var missing []string
+	for _, ref := range refs {
+		if _, ok := results[ref]; ok {
+			continue
+		}
+		if value := cache.Get(ref); value != nil {
+			results[ref] = RefResult{Value: value}
+			continue
+		}
+		results[ref] = RefResult{}
+		missing = append(missing, ref)
+	}
+
+	workers := concurrency
+	if workers > len(missing) {
+		workers = len(missing)
+	}
+
+	var wg sync.WaitGroup
+	var mutex sync.Mutex
+	pending := make(chan string)
+	for i := 0; i < workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for ref := range pending {
+				var result RefResult
+				if err := ctx.Err(); err != nil {
+					result.Err = err
+				} else {
+					result.Value, result.Err = getBlock(ctx, store, ref, cache)
+				}
+
+				mutex.Lock()
+				results[ref] = result
+				mutex.Unlock()
+			}
+		}()
+	}
+	for _, ref := range missing {
+		pending <- ref
+	}
+	close(pending)
+	wg.Wait()
+
+	return results
+}
+
+// ResolveRefs resolves every ref using the same BlockStore
+// and Cache as the Polymorph. See ResolveRefsWithStore
+// for details.
+func (p *Polymorph) ResolveRefs(ctx context.Context, refs []string) map[string]RefResult {
+	return ResolveRefsWithStore(ctx, p.blockStore(), refs, p.getCache(), p.getClient().concurrency())
+}
@@ -0,0 +1,106 @@
+package ipldpolymorph_test
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"runtime"
+	"strconv"
+	"testing"
+
+	ipldpolymorph "github.com/computes/go-ipld-polymorph"
+)
+
+func TestResolveRefs(t *testing.T) {
+	beforeEach()
+	httpResponses[http.MethodGet]["/api/v0/dag/get?arg=foo"] = `"bar"`
+	httpResponses[http.MethodGet]["/api/v0/dag/get?arg=red"] = `"blue"`
+
+	results := ipldpolymorph.ResolveRefs(context.Background(), ipfsURL, []string{"foo", "red", "missing", "foo"}, ipldpolymorph.NewSimpleCache())
+	if len(results) != 3 {
+		t.Fatalf(`Expected 3 results. Actual results == %v`, results)
+	}
+
+	if results["foo"].Err != nil {
+		t.Fatal(`Failed to resolve "foo":`, results["foo"].Err.Error())
+	}
+	if string(results["foo"].Value) != `"bar"` {
+		t.Errorf(`Expected foo == "bar". Actual foo == %s`, results["foo"].Value)
+	}
+	if string(results["red"].Value) != `"blue"` {
+		t.Errorf(`Expected red == "blue". Actual red == %s`, results["red"].Value)
+	}
+	if results["missing"].Err == nil {
+		t.Error(`Expected "missing" to have an error, received nil`)
+	}
+	if results["missing"].Value != nil {
+		t.Errorf(`Expected missing == nil. Actual missing == %s`, results["missing"].Value)
+	}
+}
+
+func TestResolveRefsCache(t *testing.T) {
+	beforeEach()
+	cache := ipldpolymorph.NewSimpleCache()
+	cache.Set("foo", json.RawMessage(`"bar"`))
+
+	results := ipldpolymorph.ResolveRefs(context.Background(), ipfsURL, []string{"foo"}, cache)
+	if results["foo"].Err != nil {
+		t.Fatal(`Failed to resolve "foo":`, results["foo"].Err.Error())
+	}
+	if string(results["foo"].Value) != `"bar"` {
+		t.Errorf(`Expected foo == "bar". Actual foo == %s`, results["foo"].Value)
+	}
+}
+
+func TestResolveRefsWithStoreConcurrency(t *testing.T) {
+	memory := ipldpolymorph.NewMemoryBlockStore()
+	var refs []string
+	for i := 0; i < 6; i++ {
+		ref, err := memory.Put(context.Background(), json.RawMessage(strconv.Itoa(i)))
+		if err != nil {
+			t.Fatal("Could not Put:", err.Error())
+		}
+		refs = append(refs, ref)
+	}
+	store := &slowBlockStore{BlockStore: memory}
+
+	results := ipldpolymorph.ResolveRefsWithStore(context.Background(), store, refs, ipldpolymorph.NewSimpleCache(), 3)
+	for i, ref := range refs {
+		if results[ref].Err != nil {
+			t.Fatal("Failed to resolve:", results[ref].Err.Error())
+		}
+		if string(results[ref].Value) != strconv.Itoa(i) {
+			t.Errorf(`Expected value == %v. Actual value == %s`, i, results[ref].Value)
+		}
+	}
+	if store.maxInFlight != 3 {
+		t.Fatalf(`Expected maxInFlight == 3. Actual maxInFlight == %v`, store.maxInFlight)
+	}
+}
+
+func TestResolveRefsWithStoreBoundsGoroutines(t *testing.T) {
+	memory := ipldpolymorph.NewMemoryBlockStore()
+	var refs []string
+	for i := 0; i < 200; i++ {
+		ref, err := memory.Put(context.Background(), json.RawMessage(strconv.Itoa(i)))
+		if err != nil {
+			t.Fatal("Could not Put:", err.Error())
+		}
+		refs = append(refs, ref)
+	}
+	store := &goroutineBlockStore{BlockStore: memory}
+
+	before := runtime.NumGoroutine()
+	results := ipldpolymorph.ResolveRefsWithStore(context.Background(), store, refs, ipldpolymorph.NewSimpleCache(), 2)
+	for i, ref := range refs {
+		if results[ref].Err != nil {
+			t.Fatal("Failed to resolve:", results[ref].Err.Error())
+		}
+		if string(results[ref].Value) != strconv.Itoa(i) {
+			t.Fatalf(`Expected value == %v. Actual value == %s`, i, results[ref].Value)
+		}
+	}
+	if extra := store.maxGoroutines - before; extra > 2 {
+		t.Fatalf(`Expected at most 2 extra goroutines. Actual extra goroutines == %v`, extra)
+	}
+}
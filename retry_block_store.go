@@ -0,0 +1,195 @@
+package ipldpolymorph
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"math"
+	"math/rand"
+	"net"
+	"net/http"
+	"net/url"
+	"syscall"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// DefaultRetryPolicy is a reasonable RetryPolicy
+// for talking to a local IPFS daemon
+var DefaultRetryPolicy = RetryPolicy{
+	MaxAttempts:    3,
+	InitialBackoff: 100 * time.Millisecond,
+	MaxBackoff:     2 * time.Second,
+	Multiplier:     2,
+	Jitter:         0.2,
+}
+
+// RetryPolicy describes how failed BlockStore
+// calls are retried
+type RetryPolicy struct {
+	// MaxAttempts is the total number of attempts,
+	// including the first one. Values below 2
+	// disable retrying.
+	MaxAttempts int
+
+	// InitialBackoff is how long to wait
+	// before the first retry
+	InitialBackoff time.Duration
+
+	// MaxBackoff caps the wait between attempts.
+	// Zero means there is no cap.
+	MaxBackoff time.Duration
+
+	// Multiplier grows the wait after every
+	// attempt. Values below 1 mean 2 is used.
+	Multiplier float64
+
+	// Jitter randomly shortens every wait by up to
+	// this fraction of it, so that many clients don't
+	// retry in lockstep. Must be between 0 and 1.
+	Jitter float64
+
+	// Retryable decides if an error is worth retrying.
+	// IsRetryable is used if it is nil.
+	Retryable func(error) bool
+}
+
+// RetryBlockStore implements BlockStore by retrying
+// the Gets and Puts of another BlockStore according
+// to a RetryPolicy
+type RetryBlockStore struct {
+	store  BlockStore
+	policy RetryPolicy
+}
+
+// NewRetryBlockStore returns an instance of RetryBlockStore
+// wrapping store, which can be used as BlockStore
+func NewRetryBlockStore(store BlockStore, policy RetryPolicy) BlockStore {
+	return &RetryBlockStore{store: store, policy: policy}
+}
+
+// Get returns the raw JSON stored at ref, retrying
+// transient failures
+func (s *RetryBlockStore) Get(ctx context.Context, ref string) (json.RawMessage, error) {
+	var value json.RawMessage
+	err := s.policy.do(ctx, func() error {
+		var err error
+		value, err = s.store.Get(ctx, ref)
+		return err
+	})
+	return value, err
+}
+
+// Put stores the raw JSON and returns its ref,
+// retrying transient failures
+func (s *RetryBlockStore) Put(ctx context.Context, raw json.RawMessage) (string, error) {
+	var ref string
+	err := s.policy.do(ctx, func() error {
+		var err error
+		ref, err = s.store.Put(ctx, raw)
+		return err
+	})
+	return ref, err
+}
+
+// retryKey identifies fetches made through a RetryBlockStore,
+// keeping them apart from those made through the wrapped
+// store, whose callers would not retry on their behalf
+type retryKey struct {
+	store interface{}
+}
+
+// blockStoreKey coalesces fetches with those made
+// through other RetryBlockStores wrapping the same store
+func (s *RetryBlockStore) blockStoreKey() interface{} {
+	key := blockStoreKey(s.store)
+	if key == nil {
+		return nil
+	}
+	return retryKey{store: key}
+}
+
+// IsRetryable reports whether err is a transient failure.
+// Timeouts, refused or reset connections, connections closed
+// early, and 5xx or 429 responses from the IPFS API are
+// transient. ErrBlockNotFound, cancelled contexts, and
+// everything else, e.g. an unsupported URL scheme, a TLS
+// failure or an unknown host, are not.
+func IsRetryable(err error) bool {
+	cause := errors.Cause(err)
+	if cause == nil || cause == ErrBlockNotFound || isContextError(cause) {
+		return false
+	}
+	if cause == io.EOF || cause == io.ErrUnexpectedEOF {
+		return true
+	}
+
+	switch typed := cause.(type) {
+	case *StatusError:
+		return typed.StatusCode >= 500 || typed.StatusCode == http.StatusTooManyRequests
+	case *url.Error:
+		return isTransientNetError(typed.Err)
+	case net.Error:
+		return isTransientNetError(typed)
+	}
+	return false
+}
+
+// isTransientNetError reports whether err, as
+// returned by a net.Conn or http.Client, is
+// worth retrying
+func isTransientNetError(err error) bool {
+	if errors.Is(err, io.EOF) || errors.Is(err, io.ErrUnexpectedEOF) {
+		return true
+	}
+	if errors.Is(err, syscall.ECONNRESET) || errors.Is(err, syscall.ECONNREFUSED) {
+		return true
+	}
+
+	var dnsErr *net.DNSError
+	if errors.As(err, &dnsErr) {
+		return dnsErr.IsTimeout || dnsErr.IsTemporary
+	}
+	var netErr net.Error
+	return errors.As(err, &netErr) && netErr.Timeout()
+}
+
+// do calls fn until it succeeds, fails with an error
+// that is not retryable, or runs out of attempts
+func (p RetryPolicy) do(ctx context.Context, fn func() error) error {
+	retryable := p.Retryable
+	if retryable == nil {
+		retryable = IsRetryable
+	}
+
+	for attempt := 1; ; attempt++ {
+		err := fn()
+		if err == nil || attempt >= p.MaxAttempts || !retryable(err) {
+			return err
+		}
+
+		select {
+		case <-time.After(p.backoff(attempt)):
+		case <-ctx.Done():
+			return errors.Wrapf(err, "gave up retrying after %v attempts: %v", attempt, ctx.Err())
+		}
+	}
+}
+
+// backoff returns how long to wait after
+// the given number of failed attempts
+func (p RetryPolicy) backoff(attempt int) time.Duration {
+	multiplier := p.Multiplier
+	if multiplier < 1 {
+		multiplier = 2
+	}
+
+	backoff := float64(p.InitialBackoff) * math.Pow(multiplier, float64(attempt-1))
+	if p.MaxBackoff > 0 && backoff > float64(p.MaxBackoff) {
+		backoff = float64(p.MaxBackoff)
+	}
+	backoff -= backoff * p.Jitter * rand.Float64()
+
+	return time.Duration(backoff)
+}
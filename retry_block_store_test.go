@@ -0,0 +1,277 @@
+package ipldpolymorph_test
+
+import (
+	"context"
+	"encoding/json"
+	"net"
+	"net/http"
+	"net/url"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	ipldpolymorph "github.com/computes/go-ipld-polymorph"
+	"github.com/pkg/errors"
+)
+
+// flakyBlockStore fails the first failures calls to
+// Get and Put with err, then delegates to BlockStore
+type flakyBlockStore struct {
+	ipldpolymorph.BlockStore
+	failures int
+	err      error
+	calls    int
+}
+
+func (s *flakyBlockStore) Get(ctx context.Context, ref string) (json.RawMessage, error) {
+	s.calls++
+	if s.calls <= s.failures {
+		return nil, s.err
+	}
+	return s.BlockStore.Get(ctx, ref)
+}
+
+func (s *flakyBlockStore) Put(ctx context.Context, raw json.RawMessage) (string, error) {
+	s.calls++
+	if s.calls <= s.failures {
+		return "", s.err
+	}
+	return s.BlockStore.Put(ctx, raw)
+}
+
+var testRetryPolicy = ipldpolymorph.RetryPolicy{
+	MaxAttempts:    3,
+	InitialBackoff: time.Millisecond,
+	MaxBackoff:     5 * time.Millisecond,
+	Jitter:         0.5,
+}
+
+func TestRetryBlockStoreGet(t *testing.T) {
+	memory := ipldpolymorph.NewMemoryBlockStore()
+	ref, err := memory.Put(context.Background(), json.RawMessage(`"bar"`))
+	if err != nil {
+		t.Fatal("Could not Put:", err.Error())
+	}
+	flaky := &flakyBlockStore{
+		BlockStore: memory,
+		failures:   2,
+		err:        &ipldpolymorph.StatusError{StatusCode: http.StatusServiceUnavailable},
+	}
+	store := ipldpolymorph.NewRetryBlockStore(flaky, testRetryPolicy)
+
+	raw, err := store.Get(context.Background(), ref)
+	if err != nil {
+		t.Fatal("Could not Get:", err.Error())
+	}
+	if string(raw) != `"bar"` {
+		t.Fatalf(`Expected raw == "bar". Actual raw == %s`, raw)
+	}
+	if flaky.calls != 3 {
+		t.Fatalf(`Expected calls == 3. Actual calls == %v`, flaky.calls)
+	}
+}
+
+func TestRetryBlockStorePutGivesUp(t *testing.T) {
+	flaky := &flakyBlockStore{
+		BlockStore: ipldpolymorph.NewMemoryBlockStore(),
+		failures:   5,
+		err:        errors.Wrap(&ipldpolymorph.StatusError{StatusCode: http.StatusBadGateway}, "dag put failed"),
+	}
+	store := ipldpolymorph.NewRetryBlockStore(flaky, testRetryPolicy)
+
+	ref, err := store.Put(context.Background(), json.RawMessage(`"bar"`))
+	if err == nil {
+		t.Fatal("Expected Put to return an error, received nil")
+	}
+	if ref != "" {
+		t.Fatalf(`Expected ref == "". Actual ref == "%v"`, ref)
+	}
+	if flaky.calls != 3 {
+		t.Fatalf(`Expected calls == 3. Actual calls == %v`, flaky.calls)
+	}
+}
+
+func TestRetryBlockStoreNotFound(t *testing.T) {
+	flaky := &flakyBlockStore{BlockStore: ipldpolymorph.NewMemoryBlockStore()}
+	store := ipldpolymorph.NewRetryBlockStore(flaky, testRetryPolicy)
+
+	_, err := store.Get(context.Background(), "foo")
+	if errors.Cause(err) != ipldpolymorph.ErrBlockNotFound {
+		t.Fatal("Expected Get to return ErrBlockNotFound, received", err)
+	}
+	if flaky.calls != 1 {
+		t.Fatalf(`Expected calls == 1. Actual calls == %v`, flaky.calls)
+	}
+}
+
+func TestRetryBlockStoreNotRetryable(t *testing.T) {
+	flaky := &flakyBlockStore{
+		BlockStore: ipldpolymorph.NewMemoryBlockStore(),
+		failures:   1,
+		err:        &ipldpolymorph.StatusError{StatusCode: http.StatusBadRequest},
+	}
+	store := ipldpolymorph.NewRetryBlockStore(flaky, testRetryPolicy)
+
+	_, err := store.Get(context.Background(), "foo")
+	if err == nil {
+		t.Fatal("Expected Get to return an error, received nil")
+	}
+	if flaky.calls != 1 {
+		t.Fatalf(`Expected calls == 1. Actual calls == %v`, flaky.calls)
+	}
+}
+
+func TestRetryBlockStoreCustomRetryable(t *testing.T) {
+	flaky := &flakyBlockStore{
+		BlockStore: ipldpolymorph.NewMemoryBlockStore(),
+		failures:   5,
+		err:        errors.New("try again"),
+	}
+	policy := testRetryPolicy
+	policy.MaxAttempts = 4
+	policy.Retryable = func(err error) bool { return err.Error() == "try again" }
+	store := ipldpolymorph.NewRetryBlockStore(flaky, policy)
+
+	_, err := store.Get(context.Background(), "foo")
+	if err == nil {
+		t.Fatal("Expected Get to return an error, received nil")
+	}
+	if flaky.calls != 4 {
+		t.Fatalf(`Expected calls == 4. Actual calls == %v`, flaky.calls)
+	}
+}
+
+func TestRetryBlockStoreContextCancelled(t *testing.T) {
+	flaky := &flakyBlockStore{
+		BlockStore: ipldpolymorph.NewMemoryBlockStore(),
+		failures:   5,
+		err:        &ipldpolymorph.StatusError{StatusCode: http.StatusServiceUnavailable},
+	}
+	policy := testRetryPolicy
+	policy.InitialBackoff = time.Hour
+	policy.MaxBackoff = 0
+	store := ipldpolymorph.NewRetryBlockStore(flaky, policy)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	_, err := store.Get(ctx, "foo")
+	if err == nil {
+		t.Fatal("Expected Get to return an error, received nil")
+	}
+	if flaky.calls != 1 {
+		t.Fatalf(`Expected calls == 1. Actual calls == %v`, flaky.calls)
+	}
+}
+
+func TestIPFSBlockStoreNotFound(t *testing.T) {
+	beforeEach()
+	store := ipldpolymorph.NewIPFSBlockStore(ipfsURL)
+
+	_, err := store.Get(context.Background(), "foo")
+	if errors.Cause(err) != ipldpolymorph.ErrBlockNotFound {
+		t.Fatal("Expected Get to return ErrBlockNotFound, received", err)
+	}
+	if ipldpolymorph.IsRetryable(err) {
+		t.Fatal("Expected IsRetryable to be false, was true")
+	}
+}
+
+func TestRetryBlockStoreConnectionRefused(t *testing.T) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal("Could not Listen:", err.Error())
+	}
+	refusedURL := &url.URL{Scheme: "http", Host: listener.Addr().String()}
+	listener.Close()
+
+	flaky := &flakyBlockStore{BlockStore: ipldpolymorph.NewIPFSBlockStore(refusedURL)}
+	store := ipldpolymorph.NewRetryBlockStore(flaky, testRetryPolicy)
+
+	_, err = store.Get(context.Background(), "foo")
+	if err == nil {
+		t.Fatal("Expected Get to return an error, received nil")
+	}
+	if flaky.calls != 3 {
+		t.Fatalf(`Expected calls == 3. Actual calls == %v`, flaky.calls)
+	}
+}
+
+func TestRetryBlockStoreUnsupportedScheme(t *testing.T) {
+	badURL := &url.URL{Scheme: "foo", Host: ipfsURL.Host}
+	flaky := &flakyBlockStore{BlockStore: ipldpolymorph.NewIPFSBlockStore(badURL)}
+	store := ipldpolymorph.NewRetryBlockStore(flaky, testRetryPolicy)
+
+	_, err := store.Get(context.Background(), "foo")
+	if err == nil {
+		t.Fatal("Expected Get to return an error, received nil")
+	}
+	if ipldpolymorph.IsRetryable(err) {
+		t.Fatal("Expected IsRetryable to be false, was true")
+	}
+	if flaky.calls != 1 {
+		t.Fatalf(`Expected calls == 1. Actual calls == %v`, flaky.calls)
+	}
+}
+
+// busyOnceBlockStore blocks the first Get until release
+// is closed, then fails it with a 503. Every later Get
+// delegates to BlockStore.
+type busyOnceBlockStore struct {
+	ipldpolymorph.BlockStore
+	gets    int32
+	release chan struct{}
+}
+
+func (s *busyOnceBlockStore) Get(ctx context.Context, ref string) (json.RawMessage, error) {
+	if atomic.AddInt32(&s.gets, 1) == 1 {
+		<-s.release
+		return nil, &ipldpolymorph.StatusError{StatusCode: http.StatusServiceUnavailable, Message: "busy"}
+	}
+	return s.BlockStore.Get(ctx, ref)
+}
+
+func TestRetryBlockStoreNotCoalescedWithWrapped(t *testing.T) {
+	memory := ipldpolymorph.NewMemoryBlockStore()
+	ref, err := memory.Put(context.Background(), json.RawMessage(`"bar"`))
+	if err != nil {
+		t.Fatal("Could not Put:", err.Error())
+	}
+	busy := &busyOnceBlockStore{BlockStore: memory, release: make(chan struct{})}
+	raw := json.RawMessage(`{"/":"` + ref + `"}`)
+
+	plainErr := make(chan error)
+	go func() {
+		_, err := ipldpolymorph.ResolveRefWithStore(context.Background(), busy, raw, ipldpolymorph.NewSimpleCache())
+		plainErr <- err
+	}()
+	for atomic.LoadInt32(&busy.gets) == 0 {
+		time.Sleep(time.Millisecond)
+	}
+
+	type result struct {
+		value json.RawMessage
+		err   error
+	}
+	retried := make(chan result)
+	go func() {
+		store := ipldpolymorph.NewRetryBlockStore(busy, testRetryPolicy)
+		value, err := ipldpolymorph.ResolveRefWithStore(context.Background(), store, raw, ipldpolymorph.NewSimpleCache())
+		retried <- result{value, err}
+	}()
+	time.Sleep(50 * time.Millisecond)
+	close(busy.release)
+
+	if err := <-plainErr; err == nil {
+		t.Error("Expected ResolveRefWithStore to return an error, received nil")
+	}
+	res := <-retried
+	if res.err != nil {
+		t.Fatal("Failed to ResolveRefWithStore:", res.err.Error())
+	}
+	if string(res.value) != `"bar"` {
+		t.Fatalf(`Expected res == "bar". Actual res == %s`, res.value)
+	}
+	if gets := atomic.LoadInt32(&busy.gets); gets != 2 {
+		t.Fatalf(`Expected gets == 2. Actual gets == %v`, gets)
+	}
+}
@@ -5,7 +5,9 @@ import (
 	"net/http"
 	"net/http/httptest"
 	"net/url"
+	"sync"
 	"testing"
+	"time"
 )
 
 var ipfsURL *url.URL
@@ -13,6 +15,13 @@ var server *http.Server
 
 var httpResponses map[string]map[string]string
 
+// httpDelay is how long every response is held back
+var httpDelay time.Duration
+
+// httpRequests counts the requests per method and path
+var httpRequests map[string]int
+var httpRequestsMutex sync.Mutex
+
 func TestMain(m *testing.M) {
 	ts := httptest.NewServer(http.HandlerFunc(handleResponse))
 	defer ts.Close()
@@ -28,11 +37,27 @@ func TestMain(m *testing.M) {
 
 func beforeEach() {
 	httpResponses = map[string]map[string]string{
-		http.MethodGet: map[string]string{},
+		http.MethodGet:  map[string]string{},
+		http.MethodPost: map[string]string{},
 	}
+	httpDelay = 0
+	httpRequestsMutex.Lock()
+	httpRequests = map[string]int{}
+	httpRequestsMutex.Unlock()
+}
+
+func countRequests(method, path string) int {
+	httpRequestsMutex.Lock()
+	defer httpRequestsMutex.Unlock()
+	return httpRequests[method+" "+path]
 }
 
 func handleResponse(w http.ResponseWriter, r *http.Request) {
+	httpRequestsMutex.Lock()
+	httpRequests[r.Method+" "+r.URL.Path+"?"+r.URL.RawQuery]++
+	httpRequestsMutex.Unlock()
+	time.Sleep(httpDelay)
+
 	responses, ok := httpResponses[r.Method]
 	if !ok {
 		http.NotFound(w, r)
@@ -0,0 +1,135 @@
+package ipldpolymorph
+
+import (
+	"context"
+	"encoding/json"
+
+	"github.com/pkg/errors"
+)
+
+// ShardOptions configures how CalcRefShardedWithStore
+// splits a document into several blocks
+type ShardOptions struct {
+	// MaxBlockSize is the size in bytes above which an
+	// object or array is put into a block of its own.
+	// Zero means there is no limit.
+	MaxBlockSize int
+
+	// Paths lists the values that are always put
+	// into blocks of their own. Array elements are
+	// addressed by their non negative index.
+	Paths []Path
+}
+
+// CalcRefShardedWithStore puts the raw JSON into store, splitting
+// it into several blocks as configured by opts, and returns
+// the ref of the root block. Objects and arrays are put
+// bottom-up, so the size of a value is measured after its
+// children were replaced by links. Every value split off
+// is replaced by an IPLD reference to its block, so the
+// document reads back the same through the Get* methods.
+func CalcRefShardedWithStore(ctx context.Context, store BlockStore, raw json.Marshaler, opts ShardOptions) (string, error) {
+	if raw == nil {
+		return "", errors.Errorf("Polymorph.raw is nil")
+	}
+	buf, err := raw.MarshalJSON()
+	if err != nil {
+		return "", errors.Wrap(err, "Unable to MarshalJSON from RawMessage")
+	}
+
+	s := &sharder{store: store, opts: opts, paths: make(map[string]bool)}
+	for _, path := range opts.Paths {
+		s.paths[path.String()] = true
+	}
+
+	root, err := s.shardChildren(ctx, json.RawMessage(buf), Path{})
+	if err != nil {
+		return "", err
+	}
+	return store.Put(ctx, root)
+}
+
+// CalcRefSharded returns the ref of a raw message by putting
+// it into the dag split into several blocks as configured by
+// opts. See CalcRefShardedWithStore for details.
+func (p *Polymorph) CalcRefSharded(opts ShardOptions) (string, error) {
+	return p.CalcRefShardedContext(context.Background(), opts)
+}
+
+// CalcRefShardedContext returns the ref of a raw message by putting
+// it into the dag split into several blocks as configured by
+// opts. See CalcRefShardedWithStore for details.
+func (p *Polymorph) CalcRefShardedContext(ctx context.Context, opts ShardOptions) (string, error) {
+	if p.IsRef() {
+		return p.AsRef(), nil
+	}
+	return CalcRefShardedWithStore(ctx, p.blockStore(), p.raw, opts)
+}
+
+// sharder holds the state of a single call to CalcRefShardedWithStore
+type sharder struct {
+	store BlockStore
+	opts  ShardOptions
+	paths map[string]bool
+}
+
+// shard returns raw, which is the value at path, with every
+// value to split off replaced by a link, including raw itself
+func (s *sharder) shard(ctx context.Context, raw json.RawMessage, path Path) (json.RawMessage, error) {
+	raw, err := s.shardChildren(ctx, raw, path)
+	if err != nil {
+		return nil, err
+	}
+
+	if !isContainer(raw) || IsRef(raw) {
+		return raw, nil
+	}
+
+	tooBig := s.opts.MaxBlockSize > 0 && len(raw) > s.opts.MaxBlockSize
+	if !tooBig && !s.paths[path.String()] {
+		return raw, nil
+	}
+
+	ref, err := s.store.Put(ctx, raw)
+	if err != nil {
+		return nil, errors.Wrapf(err, `Unable to Put "%v"`, path)
+	}
+	return refLink(ref), nil
+}
+
+// shardChildren returns raw, which is the value at path, with
+// every value to split off inside of it replaced by a link
+func (s *sharder) shardChildren(ctx context.Context, raw json.RawMessage, path Path) (json.RawMessage, error) {
+	if !isContainer(raw) || IsRef(raw) {
+		return raw, nil
+	}
+
+	c, err := decodeContainer(raw)
+	if err != nil {
+		return nil, errors.Wrapf(err, `Unable to decode "%v"`, path)
+	}
+
+	if c.isArray {
+		for i, child := range c.array {
+			c.array[i], err = s.shard(ctx, child, path.AppendIndex(i))
+			if err != nil {
+				return nil, err
+			}
+		}
+	} else {
+		for key, child := range c.object {
+			c.object[key], err = s.shard(ctx, child, path.Append(key))
+			if err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	return c.encode()
+}
+
+// isContainer reports whether raw is a JSON object or array
+func isContainer(raw json.RawMessage) bool {
+	first := firstByte(raw)
+	return first == '{' || first == '['
+}
@@ -0,0 +1,95 @@
+package ipldpolymorph_test
+
+import (
+	"context"
+	"testing"
+
+	ipldpolymorph "github.com/computes/go-ipld-polymorph"
+)
+
+func TestCalcRefShardedBySize(t *testing.T) {
+	store := ipldpolymorph.NewMemoryBlockStore()
+	p, err := ipldpolymorph.FromInterface(ipfsURL, map[string]interface{}{
+		"small": map[string]string{"a": "b"},
+		"large": map[string]interface{}{
+			"description": "a value long enough to exceed the limit",
+			"list":        []int{1, 2, 3},
+		},
+	})
+	if err != nil {
+		t.Fatal("Could not FromInterface:", err.Error())
+	}
+	p.BlockStore = store
+
+	ref, err := p.CalcRefSharded(ipldpolymorph.ShardOptions{MaxBlockSize: 32})
+	if err != nil {
+		t.Fatal("Could not CalcRefSharded:", err.Error())
+	}
+
+	root, err := store.Get(context.Background(), ref)
+	if err != nil {
+		t.Fatal("Could not Get the root block:", err.Error())
+	}
+	sharded := ipldpolymorph.NewWithBlockStore(store)
+	sharded.UnmarshalJSON(root)
+
+	large, err := sharded.GetUnresolvedPolymorph("large")
+	if err != nil {
+		t.Fatal(`Could not GetUnresolvedPolymorph for path "large":`, err.Error())
+	}
+	if !large.IsRef() {
+		t.Fatal(`Expected "large" to be split into its own block`)
+	}
+	small, err := sharded.GetUnresolvedPolymorph("small")
+	if err != nil {
+		t.Fatal(`Could not GetUnresolvedPolymorph for path "small":`, err.Error())
+	}
+	if small.IsRef() {
+		t.Fatal(`Expected "small" to stay inline`)
+	}
+
+	description, err := sharded.GetString("large/description")
+	if err != nil {
+		t.Fatal(`Could not GetString for path "large/description":`, err.Error())
+	}
+	if description != "a value long enough to exceed the limit" {
+		t.Fatalf(`Expected description == "a value long enough to exceed the limit". Actual description == "%v"`, description)
+	}
+}
+
+func TestCalcRefShardedByPath(t *testing.T) {
+	store := ipldpolymorph.NewMemoryBlockStore()
+	p, err := ipldpolymorph.FromInterface(ipfsURL, map[string]interface{}{
+		"steps": []map[string]string{{"name": "build"}, {"name": "test"}},
+	})
+	if err != nil {
+		t.Fatal("Could not FromInterface:", err.Error())
+	}
+	p.BlockStore = store
+
+	ref, err := p.CalcRefSharded(ipldpolymorph.ShardOptions{
+		Paths: []ipldpolymorph.Path{{"steps", "1"}},
+	})
+	if err != nil {
+		t.Fatal("Could not CalcRefSharded:", err.Error())
+	}
+
+	sharded := ipldpolymorph.NewWithBlockStore(store)
+	sharded.UnmarshalJSON([]byte(`{"/":"` + ref + `"}`))
+
+	step, err := sharded.GetUnresolvedPolymorph("steps/1")
+	if err != nil {
+		t.Fatal(`Could not GetUnresolvedPolymorph for path "steps/1":`, err.Error())
+	}
+	if !step.IsRef() {
+		t.Fatal(`Expected "steps/1" to be split into its own block`)
+	}
+
+	name, err := sharded.GetString("steps/1/name")
+	if err != nil {
+		t.Fatal(`Could not GetString for path "steps/1/name":`, err.Error())
+	}
+	if name != "test" {
+		t.Fatalf(`Expected name == "test". Actual name == "%v"`, name)
+	}
+}
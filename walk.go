@@ -0,0 +1,143 @@
+package ipldpolymorph
+
+import (
+	"context"
+	"encoding/json"
+	"sort"
+
+	"github.com/pkg/errors"
+)
+
+// SkipSubtree can be returned by a WalkFunc to skip the
+// children of the node it was called for. It is never
+// returned by Walk itself.
+var SkipSubtree = errors.New("skip this subtree")
+
+// WalkFunc is called by Walk for every node of the document.
+// path is the path from the root of the document to the node,
+// node is the value stored there, and isLink is true if that
+// value is an IPLD reference. Returning SkipSubtree skips the
+// node's children, any other error stops the Walk.
+type WalkFunc func(path Path, node *Polymorph, isLink bool) error
+
+// WalkOptions configures how Walk traverses a document
+type WalkOptions struct {
+	// FollowLinks makes Walk resolve IPLD references and
+	// traverse their content. Otherwise references are
+	// visited as leaves.
+	FollowLinks bool
+
+	// PostOrder makes Walk call the WalkFunc for the
+	// children of a node before the node itself.
+	PostOrder bool
+}
+
+// Walk calls fn for every node of the logical document,
+// following IPLD references. See WalkContext for details.
+func (p *Polymorph) Walk(fn WalkFunc) error {
+	return p.WalkContext(context.Background(), WalkOptions{FollowLinks: true}, fn)
+}
+
+// WalkWithOptions calls fn for every node of the document,
+// as configured by opts. See WalkContext for details.
+func (p *Polymorph) WalkWithOptions(opts WalkOptions, fn WalkFunc) error {
+	return p.WalkContext(context.Background(), opts, fn)
+}
+
+// WalkContext calls fn for every node of the document, starting
+// with the root, whose path is empty. Object members are visited
+// in sorted key order, array elements in index order. An IPLD
+// reference is passed to fn unresolved, and when following links
+// its content is traversed as the reference's children. Walk
+// stops with a *LinkChainError when a reference cycle is found.
+// SkipSubtree has no effect when walking in post order.
+func (p *Polymorph) WalkContext(ctx context.Context, opts WalkOptions, fn WalkFunc) error {
+	if p.raw == nil {
+		return errors.Errorf("Polymorph.raw is nil")
+	}
+
+	w := &walker{polymorph: p, opts: opts, fn: fn}
+	return w.walk(ctx, p.raw, nil, Path{})
+}
+
+// walker holds the state of a single call to Walk
+type walker struct {
+	polymorph *Polymorph
+	opts      WalkOptions
+	fn        WalkFunc
+}
+
+// walk visits raw, which is the value at path, and its
+// children. chain holds the refs followed to get to raw.
+func (w *walker) walk(ctx context.Context, raw json.RawMessage, chain []string, path Path) error {
+	node := w.polymorph.derive(raw)
+	isLink := IsRef(raw)
+
+	if !w.opts.PostOrder {
+		err := w.fn(path, node, isLink)
+		if err == SkipSubtree {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+	}
+
+	if err := w.walkChildren(ctx, raw, isLink, chain, path); err != nil {
+		return err
+	}
+
+	if w.opts.PostOrder {
+		err := w.fn(path, node, isLink)
+		if err != nil && err != SkipSubtree {
+			return err
+		}
+	}
+	return nil
+}
+
+func (w *walker) walkChildren(ctx context.Context, raw json.RawMessage, isLink bool, chain []string, path Path) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	if isLink {
+		if !w.opts.FollowLinks {
+			return nil
+		}
+		var err error
+		raw, chain, err = w.polymorph.resolve(ctx, raw, chain)
+		if err != nil {
+			return errors.Wrapf(err, `Unable to resolve "%v"`, path)
+		}
+	}
+
+	if !isContainer(raw) {
+		return nil
+	}
+	c, err := decodeContainer(raw)
+	if err != nil {
+		return errors.Wrapf(err, `Unable to decode "%v"`, path)
+	}
+
+	if c.isArray {
+		for i, child := range c.array {
+			if err := w.walk(ctx, child, chain, path.AppendIndex(i)); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+
+	keys := make([]string, 0, len(c.object))
+	for key := range c.object {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		if err := w.walk(ctx, c.object[key], chain, path.Append(key)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
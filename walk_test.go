@@ -0,0 +1,88 @@
+package ipldpolymorph_test
+
+import (
+	"strings"
+	"testing"
+
+	ipldpolymorph "github.com/computes/go-ipld-polymorph"
+)
+
+func TestWalk(t *testing.T) {
+	store := ipldpolymorph.NewMemoryBlockStore()
+	refs := putLinked(t, store,
+		`{"c":"red"}`,
+		`{"b":{"/":"$0"},"list":[1,2]}`,
+	)
+	p := ipldpolymorph.NewWithBlockStore(store)
+	p.UnmarshalJSON([]byte(`{"/":"` + refs[1] + `"}`))
+
+	visited := []string{}
+	err := p.Walk(func(path ipldpolymorph.Path, node *ipldpolymorph.Polymorph, isLink bool) error {
+		entry := path.String()
+		if isLink {
+			entry += "*"
+		}
+		visited = append(visited, entry)
+		return nil
+	})
+	if err != nil {
+		t.Fatal("Could not Walk:", err.Error())
+	}
+
+	expected := "* /b* /b/c /list /list/0 /list/1"
+	if strings.Join(visited, " ") != expected {
+		t.Fatalf(`Expected visited == "%v". Actual visited == "%v"`, expected, strings.Join(visited, " "))
+	}
+}
+
+func TestWalkPostOrderWithoutLinks(t *testing.T) {
+	p, err := ipldpolymorph.FromInterface(ipfsURL, map[string]interface{}{
+		"a":    map[string]string{"b": "c"},
+		"link": map[string]string{"/": "never-fetched"},
+	})
+	if err != nil {
+		t.Fatal("Could not FromInterface:", err.Error())
+	}
+
+	visited := []string{}
+	opts := ipldpolymorph.WalkOptions{PostOrder: true}
+	err = p.WalkWithOptions(opts, func(path ipldpolymorph.Path, node *ipldpolymorph.Polymorph, isLink bool) error {
+		visited = append(visited, path.String())
+		return nil
+	})
+	if err != nil {
+		t.Fatal("Could not WalkWithOptions:", err.Error())
+	}
+
+	expected := "/a/b /a /link "
+	if strings.Join(visited, " ") != expected {
+		t.Fatalf(`Expected visited == "%v". Actual visited == "%v"`, expected, strings.Join(visited, " "))
+	}
+}
+
+func TestWalkSkipSubtree(t *testing.T) {
+	p, err := ipldpolymorph.FromInterface(ipfsURL, map[string]interface{}{
+		"skipped": map[string]string{"b": "c"},
+		"kept":    map[string]string{"d": "e"},
+	})
+	if err != nil {
+		t.Fatal("Could not FromInterface:", err.Error())
+	}
+
+	visited := []string{}
+	err = p.Walk(func(path ipldpolymorph.Path, node *ipldpolymorph.Polymorph, isLink bool) error {
+		visited = append(visited, path.String())
+		if path.String() == "/skipped" {
+			return ipldpolymorph.SkipSubtree
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatal("Could not Walk:", err.Error())
+	}
+
+	expected := " /kept /kept/d /skipped"
+	if strings.Join(visited, " ") != expected {
+		t.Fatalf(`Expected visited == "%v". Actual visited == "%v"`, expected, strings.Join(visited, " "))
+	}
+}